@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// CheckoutBranchName returns the local branch name for a pull request using the
+// name_<issue> convention so that DetectIssueNumber recognizes it
+func CheckoutBranchName(headRef string, number int) string {
+	if DetectIssueNumber(headRef) == number {
+		return headRef
+	}
+	return fmt.Sprintf("%s_%d", headRef, number)
+}
+
+// runCheckout implements `git-open-pull checkout <number>`
+func runCheckout(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("checkout", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: git-open-pull checkout <number>")
+	}
+	number, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid pull request number %q", fs.Arg(0))
+	}
+
+	settings, client, err := commandSetup(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	pr, _, err := client.PullRequests.Get(ctx, settings.BaseAccount, settings.BaseRepo, number)
+	if isNotFound(err) {
+		return fmt.Errorf("%s/%s#%d is not a pull request", settings.BaseAccount, settings.BaseRepo, number)
+	}
+	if err != nil {
+		return err
+	}
+
+	headRef := pr.GetHead().GetRef()
+	branch := CheckoutBranchName(headRef, number)
+	if _, err := RunGit(ctx, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		fmt.Printf("switching to existing branch %s\n", branch)
+		_, err = RunGit(ctx, "checkout", branch)
+		return err
+	}
+
+	headRepo := pr.GetHead().GetRepo()
+	if headRepo == nil {
+		// the head repository was deleted; the commits are still available from the base repository
+		fmt.Printf("fetching pull/%d/head from %s\n", number, settings.BaseAccount)
+		_, err = RunGit(ctx, "fetch", settings.BaseAccount, fmt.Sprintf("+refs/pull/%d/head:refs/heads/%s", number, branch))
+		if err != nil {
+			return err
+		}
+		_, err = RunGit(ctx, "checkout", branch)
+		return err
+	}
+
+	remote, err := ensureRemote(ctx, settings, headRepo)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("fetching %s from %s\n", headRef, remote)
+	_, err = RunGit(ctx, "fetch", remote, fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", headRef, remote, headRef))
+	if err != nil {
+		return err
+	}
+	_, err = RunGit(ctx, "checkout", "-b", branch, "--track", fmt.Sprintf("%s/%s", remote, headRef))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("checked out %s tracking %s/%s\n", branch, remote, headRef)
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckoutBranchName(t *testing.T) {
+	type testCase struct {
+		headRef string
+		number  int
+		branch  string
+	}
+	tests := []testCase{
+		{"feature_12", 12, "feature_12"},
+		{"feature", 12, "feature_12"},
+		{"feature_11", 12, "feature_11_12"},
+		{"fix-login", 7, "fix-login_7"},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			if got := CheckoutBranchName(tc.headRef, tc.number); got != tc.branch {
+				t.Errorf("got %q expected %q for %q %d", got, tc.branch, tc.headRef, tc.number)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/jehiah/git-open-pull/internal/input"
+)
+
+// PullRequestState is the state of the pull request for a local branch
+type PullRequestState struct {
+	Number int
+	// OPEN, CLOSED or MERGED
+	State      string
+	HeadBranch string
+	// the owner of the repository the head branch is in
+	HeadOwner string
+}
+
+// PullRequestStates looks up the state of several pull requests in a single
+// request. Numbers that are issues, or that don't exist, are omitted.
+func PullRequestStates(ctx context.Context, client *github.Client, settings *Settings, numbers []int) (map[int]PullRequestState, error) {
+	states := make(map[int]PullRequestState)
+	if len(numbers) == 0 {
+		return states, nil
+	}
+	var q strings.Builder
+	q.WriteString("query($owner: String!, $repo: String!) {\n  repository(owner: $owner, name: $repo) {\n")
+	for _, n := range numbers {
+		fmt.Fprintf(&q, "    pr%d: issueOrPullRequest(number: %d) { ... on PullRequest { number state headRefName headRepositoryOwner { login } } }\n", n, n)
+	}
+	q.WriteString("  }\n}")
+
+	var result struct {
+		Repository map[string]*struct {
+			Number              int
+			State               string
+			HeadRefName         string
+			HeadRepositoryOwner *struct{ Login string }
+		}
+	}
+	err := GraphQL(ctx, client, q.String(), map[string]interface{}{"owner": settings.BaseAccount, "repo": settings.BaseRepo}, &result)
+	var gqlErr GraphQLError
+	if errors.As(err, &gqlErr) {
+		for _, e := range gqlErr {
+			if e.Type != "NOT_FOUND" {
+				return nil, err
+			}
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	for _, pr := range result.Repository {
+		if pr != nil && pr.Number != 0 {
+			state := PullRequestState{Number: pr.Number, State: pr.State, HeadBranch: pr.HeadRefName}
+			if pr.HeadRepositoryOwner != nil {
+				state.HeadOwner = pr.HeadRepositoryOwner.Login
+			}
+			states[pr.Number] = state
+		}
+	}
+	return states, nil
+}
+
+// cleanupBranch is a local branch named for a pull request
+type cleanupBranch struct {
+	Branch string
+	// the remote and ref the branch tracks (see UpstreamBranch)
+	Remote, Merge string
+	PR            PullRequestState
+}
+
+// cleanupCandidates returns the branches that can be deleted: those for a
+// merged (or, with closed set, closed) pull request whose head is in
+// pushOwner's repository and is the branch's upstream on pushRemote. Other
+// branches are returned with the reason they are kept.
+func cleanupCandidates(branches []cleanupBranch, current, pushOwner, pushRemote string, closed bool) ([]cleanupBranch, map[string]string) {
+	var candidates []cleanupBranch
+	skipped := make(map[string]string)
+	for _, b := range branches {
+		switch {
+		case b.PR.State != "MERGED" && !(closed && b.PR.State == "CLOSED"):
+			skipped[b.Branch] = fmt.Sprintf("#%d is %s", b.PR.Number, strings.ToLower(b.PR.State))
+		case b.Branch == current:
+			skipped[b.Branch] = "current branch"
+		case !strings.EqualFold(b.PR.HeadOwner, pushOwner):
+			skipped[b.Branch] = fmt.Sprintf("#%d is from %s", b.PR.Number, b.PR.HeadOwner)
+		case b.Remote != pushRemote || b.Merge != "refs/heads/"+b.PR.HeadBranch:
+			skipped[b.Branch] = fmt.Sprintf("does not track %s/%s", pushRemote, b.PR.HeadBranch)
+		default:
+			candidates = append(candidates, b)
+		}
+	}
+	return candidates, skipped
+}
+
+// runCleanup implements `git-open-pull cleanup`
+func runCleanup(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	dryRun := fs.Bool("dry-run", false, "list branches that would be deleted")
+	yes := fs.Bool("yes", false, "delete without confirmation")
+	force := fs.Bool("force", false, "delete local branches even if they are not fully merged (git branch -D)")
+	closed := fs.Bool("closed", false, "also delete branches of pull requests that were closed without merging")
+	fs.Parse(args)
+
+	settings, client, err := commandSetup(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	branches, err := LocalBranches(ctx)
+	if err != nil {
+		return err
+	}
+	current, _ := GitFeatureBranch(ctx)
+
+	byNumber := make(map[int][]string)
+	var numbers []int
+	for _, b := range branches {
+		n := DetectIssueNumber(b)
+		if n == 0 {
+			continue
+		}
+		if _, ok := byNumber[n]; !ok {
+			numbers = append(numbers, n)
+		}
+		byNumber[n] = append(byNumber[n], b)
+	}
+	sort.Ints(numbers)
+	states, err := PullRequestStates(ctx, client, settings, numbers)
+	if err != nil {
+		return err
+	}
+
+	sameRepo, err := DetectSameRepo(ctx, client, settings)
+	if err != nil {
+		return err
+	}
+	pushOwner := PushOwner(settings, sameRepo)
+	// branches are pushed to the remote for pushOwner's repository, which may
+	// not be named after it (see EnsureFork)
+	pushRemote := pushOwner
+	if repo, _, err := client.Repositories.Get(ctx, pushOwner, settings.BaseRepo); err == nil {
+		if name := FindRemote(ctx, repo.GetHTMLURL()); name != "" {
+			pushRemote = name
+		}
+	}
+
+	var found []cleanupBranch
+	for _, n := range numbers {
+		pr, ok := states[n]
+		if !ok || pr.State == "OPEN" {
+			continue
+		}
+		for _, b := range byNumber[n] {
+			remote, merge := UpstreamBranch(ctx, b)
+			found = append(found, cleanupBranch{Branch: b, Remote: remote, Merge: merge, PR: pr})
+		}
+	}
+	candidates, skipped := cleanupCandidates(found, current, pushOwner, pushRemote, *closed)
+	for _, b := range found {
+		if reason, ok := skipped[b.Branch]; ok {
+			fmt.Printf("skipping %s (%s)\n", b.Branch, reason)
+		}
+	}
+	for _, c := range candidates {
+		fmt.Printf("%s\t#%d merged\n", c.Branch, c.PR.Number)
+	}
+	if len(candidates) == 0 {
+		fmt.Println("no branches for merged pull requests")
+		return nil
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes {
+		yn, err := input.Ask(fmt.Sprintf("delete %d branches locally and from %s [y/N]", len(candidates), pushRemote), "")
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(yn) != "y" {
+			return nil
+		}
+	}
+
+	deleteFlag := "-d"
+	if *force {
+		deleteFlag = "-D"
+	}
+	var failed bool
+	for _, c := range candidates {
+		// the local branch is deleted first so `git branch -d` can check it
+		// is merged into its upstream
+		fmt.Printf("deleting branch %s\n", c.Branch)
+		if err := RunGitVerbose(ctx, "branch", deleteFlag, c.Branch); err != nil {
+			failed = true
+			continue
+		}
+		if RemoteBranchSHA(ctx, pushRemote, c.PR.HeadBranch) != "" {
+			fmt.Printf("deleting %s from %s\n", c.PR.HeadBranch, pushRemote)
+			if _, err := RunGit(ctx, "push", pushRemote, "--delete", c.PR.HeadBranch); err != nil {
+				return err
+			}
+		}
+	}
+	if failed {
+		return errors.New("some branches are not fully merged; re-run with --force to delete them")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCleanupCandidates(t *testing.T) {
+	merged := func(number int, owner, head string) PullRequestState {
+		return PullRequestState{Number: number, State: "MERGED", HeadBranch: head, HeadOwner: owner}
+	}
+	type testCase struct {
+		name   string
+		branch cleanupBranch
+		closed bool
+		reason string
+	}
+	tests := []testCase{
+		{"merged", cleanupBranch{"feature_12", "origin", "refs/heads/feature_12", merged(12, "jehiah", "feature_12")}, false, ""},
+		{"owner case", cleanupBranch{"feature_12", "origin", "refs/heads/feature_12", merged(12, "Jehiah", "feature_12")}, false, ""},
+		{"closed", cleanupBranch{"feature_12", "origin", "refs/heads/feature_12", PullRequestState{Number: 12, State: "CLOSED", HeadBranch: "feature_12", HeadOwner: "jehiah"}}, false, "#12 is closed"},
+		{"current", cleanupBranch{"current_12", "origin", "refs/heads/current_12", merged(12, "jehiah", "current_12")}, false, "current branch"},
+		{"other owner", cleanupBranch{"feature_12", "origin", "refs/heads/feature_12", merged(12, "mreiferson", "feature_12")}, false, "#12 is from mreiferson"},
+		{"no upstream", cleanupBranch{"feature_12", "", "", merged(12, "jehiah", "feature_12")}, false, "does not track origin/feature_12"},
+		{"tracking main", cleanupBranch{"feature_12", "origin", "refs/heads/main", merged(12, "jehiah", "feature_12")}, false, "does not track origin/feature_12"},
+		{"other remote", cleanupBranch{"feature_12", "acme", "refs/heads/feature_12", merged(12, "jehiah", "feature_12")}, false, "does not track origin/feature_12"},
+		{"local upstream", cleanupBranch{"feature_12", ".", "refs/heads/feature_12", merged(12, "jehiah", "feature_12")}, false, "does not track origin/feature_12"},
+		{"closed included", cleanupBranch{"feature_12", "origin", "refs/heads/feature_12", PullRequestState{Number: 12, State: "CLOSED", HeadBranch: "feature_12", HeadOwner: "jehiah"}}, true, ""},
+		{"open with closed", cleanupBranch{"feature_12", "origin", "refs/heads/feature_12", PullRequestState{Number: 12, State: "OPEN", HeadBranch: "feature_12", HeadOwner: "jehiah"}}, true, "#12 is open"},
+		{"checked out as", cleanupBranch{"feature_11_12", "origin", "refs/heads/feature_11", merged(12, "jehiah", "feature_11")}, false, ""},
+	}
+	for _, tc := range tests {
+		candidates, skipped := cleanupCandidates([]cleanupBranch{tc.branch}, "current_12", "jehiah", "origin", tc.closed)
+		if tc.reason == "" {
+			if !reflect.DeepEqual(candidates, []cleanupBranch{tc.branch}) {
+				t.Errorf("%s: expected %s to be deleted; skipped %q", tc.name, tc.branch.Branch, skipped[tc.branch.Branch])
+			}
+			continue
+		}
+		if len(candidates) != 0 {
+			t.Errorf("%s: unexpected candidates %v", tc.name, candidates)
+		}
+		if got := skipped[tc.branch.Branch]; got != tc.reason {
+			t.Errorf("%s: got reason %q expected %q", tc.name, got, tc.reason)
+		}
+	}
+}
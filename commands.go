@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// subcommand is an action selected by the first command line argument
+// (i.e. `git-open-pull config show`). When no subcommand is given
+// git-open-pull opens a pull request for the current branch.
+type subcommand struct {
+	Name  string
+	Usage string
+	Run   func(ctx context.Context, args []string) error
+}
+
+var subcommands = []subcommand{
+	{Name: "config", Usage: "show, set or unset settings (config show|set|unset)", Run: runConfig},
+	{Name: "status", Usage: "show the state, checks and reviews of the pull request for the current branch", Run: runStatus},
+	{Name: "list", Usage: "list your open pull requests (--all-profiles for every configured profile)", Run: runList},
+	{Name: "checkout", Usage: "fetch and check out the branch for a pull request (checkout <number>)", Run: runCheckout},
+	{Name: "cleanup", Usage: "delete local and fork branches of merged pull requests (--closed to include closed ones)", Run: runCleanup},
+	{Name: "sync", Usage: "rebase (or merge) the current branch onto the latest base branch and push it", Run: runSync},
+	{Name: "edit", Usage: "edit the title, description and labels of the pull request in your editor", Run: runEdit},
+	{Name: "lint", Usage: "check the title and description of a pull request (or --title) against gitOpenPull.lint", Run: runLint},
+	{Name: "hooks", Usage: "list configured hooks, or run them with sample data (hooks test <stage>)", Run: runHooksCommand},
+	{Name: "ready", Usage: "mark the pull request for the current branch ready for review", Run: runReady},
+}
+
+func lookupSubcommand(name string) (subcommand, bool) {
+	for _, c := range subcommands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return subcommand{}, false
+}
+
+func printSubcommands(out io.Writer) {
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range subcommands {
+		fmt.Fprintf(out, "  %-10s %s\n", c.Name, c.Usage)
+	}
+	fmt.Fprintln(out)
+}
+
+// commandSetup reads settings without prompting and returns an API client.
+// Subcommands require settings to already be configured.
+func commandSetup(ctx context.Context, profile string) (*Settings, *github.Client, error) {
+	settings, err := readSettingsConfig(ctx, profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hints := settings.RequiredHints(); len(hints) > 0 {
+		return nil, nil, fmt.Errorf("%s", hints[0])
+	}
+	return settings, SetupClient(ctx, settings), nil
+}
+
+// pullRequestFromArgs returns the pull request numbered by the first argument,
+// or the pull request for the current branch when no argument is given
+func pullRequestFromArgs(ctx context.Context, client *github.Client, settings *Settings, args []string) (*github.PullRequest, error) {
+	if len(args) > 0 {
+		number, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pull request number %q", args[0])
+		}
+		pr, _, err := client.PullRequests.Get(ctx, settings.BaseAccount, settings.BaseRepo, number)
+		return pr, err
+	}
+	branch, err := GitFeatureBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FindPullRequest(ctx, client, settings, branch)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runConfig implements `git-open-pull config show|set|unset`
+func runConfig(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: git-open-pull config show|set|unset")
+	}
+	switch args[0] {
+	case "show":
+		return configShow(ctx, args[1:])
+	case "set":
+		return configSet(ctx, args[1:])
+	case "unset":
+		return configUnset(ctx, args[1:])
+	default:
+		return fmt.Errorf("unknown config command %q", args[0])
+	}
+}
+
+type configValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// redact hides all but the last four characters of a secret
+func redact(v string) string {
+	if len(v) <= 8 {
+		return strings.Repeat("*", len(v))
+	}
+	return strings.Repeat("*", len(v)-4) + v[len(v)-4:]
+}
+
+func configShow(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "output as JSON")
+	profile := fs.String("profile", "", "settings profile to resolve")
+	fs.Parse(args)
+
+	s, err := readSettingsConfig(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	s.warnIgnoredHooks()
+	var values []configValue
+	if s.Profile != "" {
+		values = append(values, configValue{Key: "profile", Value: s.Profile, Source: s.Source("profile")})
+	}
+	for _, k := range settingKeys {
+		v := k.value(s)
+		if k.Secret {
+			v = redact(v)
+		}
+		if k.Multi && v != "" {
+			// one row per value, like `git config --get-all`
+			for _, mv := range strings.Split(v, "\n") {
+				values = append(values, configValue{Key: k.Key, Value: mv, Source: s.Source(k.Key)})
+			}
+			continue
+		}
+		values = append(values, configValue{Key: k.Key, Value: v, Source: s.Source(k.Key)})
+	}
+	if s.BaseRepo == "" && s.DefaultBaseRepo != "" {
+		values = append(values, configValue{Key: "defaultBaseRepo", Value: s.DefaultBaseRepo, Source: "inferred from remote URL"})
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(values)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, v := range values {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, v.Value, v.Source)
+	}
+	return w.Flush()
+}
+
+// configTarget parses --global/--local overrides for the scope a setting is
+// written to, and --profile to write the setting within a named profile. It
+// returns the flag set and a func that maps a setting to its scope flag and
+// git config key.
+func configTarget(name string, args []string) (*flag.FlagSet, func(k settingKey) (string, string)) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	global := fs.Bool("global", false, "write to global git config (~/.gitconfig)")
+	local := fs.Bool("local", false, "write to repository git config (.git/config)")
+	profile := fs.String("profile", "", "write the setting to [gitOpenPull \"profile.<name>\"]")
+	fs.Parse(args)
+	return fs, func(k settingKey) (string, string) {
+		key := k.Key
+		if *profile != "" {
+			key = profileSettingKey(*profile, k)
+		}
+		switch {
+		case *global:
+			return "--global", key
+		case *local:
+			return "--local", key
+		}
+		return k.scope(), key
+	}
+}
+
+func configSet(ctx context.Context, args []string) error {
+	fs, target := configTarget("config set", args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: git-open-pull config set [--global|--local] [--profile name] <key> <value>")
+	}
+	k, ok := lookupSettingKey(fs.Arg(0))
+	if !ok {
+		return fmt.Errorf("unknown setting %q", fs.Arg(0))
+	}
+	scope, key := target(k)
+	if k.Multi {
+		// replace every command for a hook; add more with `git config --add`
+		_, err := RunGit(ctx, "config", scope, "--replace-all", key, fs.Arg(1))
+		return err
+	}
+	_, err := RunGit(ctx, "config", scope, key, fs.Arg(1))
+	return err
+}
+
+func configUnset(ctx context.Context, args []string) error {
+	fs, target := configTarget("config unset", args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: git-open-pull config unset [--global|--local] [--profile name] <key>")
+	}
+	k, ok := lookupSettingKey(fs.Arg(0))
+	if !ok {
+		return fmt.Errorf("unknown setting %q", fs.Arg(0))
+	}
+	scope, key := target(k)
+	_, err := RunGit(ctx, "config", scope, "--unset-all", key)
+	return err
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	type testCase struct {
+		value, expected string
+	}
+	tests := []testCase{
+		{"", ""},
+		{"abc", "***"},
+		{"abcdefgh", "********"},
+		{"abcdefghi", "*****fghi"},
+		{"ghp_0123456789abcdef", "****************cdef"},
+	}
+	for _, tc := range tests {
+		if got := redact(tc.value); got != tc.expected {
+			t.Errorf("redact(%q) got %q expected %q", tc.value, got, tc.expected)
+		}
+	}
+}
+
+func TestSettingsSource(t *testing.T) {
+	type testCase struct {
+		name    string
+		project string
+		config  [][]string
+		profile string
+		env     string
+		value   string
+		source  string
+	}
+	tests := []testCase{
+		{name: "unset", source: "unset"},
+		{name: "project", project: "baseAccount = \"acme\"\n", value: "acme", source: ".git-open-pull.toml:1"},
+		{
+			name:    "git config",
+			project: "baseAccount = \"acme\"\n",
+			config:  [][]string{{"--local", "gitOpenPull.baseAccount", "jehiah"}},
+			value:   "jehiah",
+			source:  "git config (local)",
+		},
+		{
+			name:   "global",
+			config: [][]string{{"--global", "gitOpenPull.baseAccount", "jehiah"}},
+			value:  "jehiah",
+			source: "git config (global)",
+		},
+		{
+			name: "profile",
+			config: [][]string{
+				{"--local", "gitOpenPull.baseAccount", "jehiah"},
+				{"--global", "gitOpenPull.profile.work.baseAccount", "acme"},
+			},
+			profile: "work",
+			value:   "acme",
+			source:  "git config (global) profile work",
+		},
+		{
+			name:    "env",
+			config:  [][]string{{"--global", "gitOpenPull.profile.work.baseAccount", "acme"}},
+			profile: "work",
+			env:     "other",
+			value:   "other",
+			source:  "env GITOPENPULL_BASE_ACCOUNT",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixtureRepo(t)
+			t.Setenv("GITOPENPULL_PROFILE", "")
+			t.Setenv("GITOPENPULL_BASE_ACCOUNT", tc.env)
+			ctx := context.Background()
+			if tc.project != "" {
+				if err := os.WriteFile(projectConfigFile, []byte(tc.project), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, args := range tc.config {
+				if _, err := RunGit(ctx, append([]string{"config"}, args...)...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s, err := readSettingsConfig(ctx, tc.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.BaseAccount != tc.value {
+				t.Errorf("got %q expected %q", s.BaseAccount, tc.value)
+			}
+			if got := s.Source("gitOpenPull.baseAccount"); got != tc.source {
+				t.Errorf("got source %q expected %q", got, tc.source)
+			}
+		})
+	}
+}
+
+func TestGetEnvSettings(t *testing.T) {
+	t.Setenv("GITOPENPULL_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	t.Setenv("GITOPENPULL_MAINTAINERS_CAN_MODIFY", "0")
+	t.Setenv("GITOPENPULL_PRE_PUSH", "scripts/check.sh")
+	s := Settings{MaintainersCanModify: true, PrePush: []string{"make test"}}
+	if err := GetEnvSettings(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Token != "github-token" || s.Source("gitOpenPull.token") != "env GITHUB_TOKEN" {
+		t.Errorf("got token %q from %q", s.Token, s.Source("gitOpenPull.token"))
+	}
+	if s.MaintainersCanModify {
+		t.Error("expected maintainersCanModify to be false")
+	}
+	if len(s.PrePush) != 1 || s.PrePush[0] != "scripts/check.sh" {
+		t.Errorf("got prePush %q expected the environment to replace it", s.PrePush)
+	}
+
+	t.Setenv("GITOPENPULL_HOOK_CONTEXT", "maybe")
+	if err := GetEnvSettings(&s); err == nil {
+		t.Error("expected an error for an invalid boolean")
+	}
+}
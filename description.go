@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Commit is a commit on the feature branch used to draft the issue description
+type Commit struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	// Body excludes trailers
+	Body   string `json:"body"`
+	Author string `json:"author"`
+	// Conventional Commit type and scope (i.e. "feat" and "api" for "feat(api): ...")
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Breaking bool   `json:"breaking"`
+	// Description is the subject without the Conventional Commit prefix
+	Description string    `json:"description"`
+	Trailers    []Trailer `json:"trailers"`
+}
+
+// Trailer is a `Key: value` line (i.e. Co-authored-by) or an issue reference
+// (i.e. `Fixes #12`) from the last paragraph of a commit message
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (t Trailer) String() string {
+	if isIssueKeyword(t.Key) {
+		return t.Key + " " + t.Value
+	}
+	return t.Key + ": " + t.Value
+}
+
+var (
+	conventionalRe = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+	trailerRe      = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|BREAKING CHANGE): (.+)$`)
+	issueRefRe     = regexp.MustCompile(`^(?i)(close[sd]?|fix(?:e[sd])?|resolve[sd]?) ([\w.-]+/[\w.-]+#\d+|#\d+)$`)
+)
+
+func isIssueKeyword(key string) bool {
+	return issueRefRe.MatchString(key + " #1")
+}
+
+// ParseCommit parses the Conventional Commit type from subject and trailers
+// from the final paragraph of body
+func ParseCommit(sha, author, subject, body string) Commit {
+	c := Commit{SHA: sha, Author: author, Subject: subject, Description: subject}
+	if m := conventionalRe.FindStringSubmatch(subject); m != nil {
+		c.Type, c.Scope, c.Breaking, c.Description = strings.ToLower(m[1]), m[2], m[3] == "!", m[4]
+	}
+
+	body = strings.TrimSpace(body)
+	paragraphs := strings.Split(body, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	var trailers []Trailer
+	for _, line := range strings.Split(last, "\n") {
+		line = strings.TrimSpace(line)
+		if m := issueRefRe.FindStringSubmatch(line); m != nil {
+			trailers = append(trailers, Trailer{m[1], m[2]})
+			continue
+		}
+		m := trailerRe.FindStringSubmatch(line)
+		if m == nil {
+			// not a trailer block
+			trailers = nil
+			break
+		}
+		trailers = append(trailers, Trailer{m[1], m[2]})
+		if strings.EqualFold(m[1], "BREAKING CHANGE") || strings.EqualFold(m[1], "BREAKING-CHANGE") {
+			c.Breaking = true
+		}
+	}
+	if len(trailers) > 0 {
+		c.Trailers = trailers
+		body = strings.TrimSpace(strings.Join(paragraphs[:len(paragraphs)-1], "\n\n"))
+	}
+	c.Body = body
+	return c
+}
+
+// LoadCommits returns the commits since base, oldest first
+func LoadCommits(ctx context.Context, base string) ([]Commit, error) {
+	hashes, err := Commits(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, h := range hashes {
+		t, b, err := CommitDetails(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+		author, err := RunGit(ctx, "show", "-s", "--format=%an <%ae>", h)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, ParseCommit(h, strings.TrimSpace(string(author)), t, b))
+	}
+	return commits, nil
+}
+
+// squashFixups removes `fixup!` and `amend!` commits and folds the body of
+// `squash!` commits into the commit they target. Autosquash commits without a
+// matching target are kept with the prefix removed.
+func squashFixups(commits []Commit) []Commit {
+	var o []Commit
+	index := make(map[string]int)
+	for _, c := range commits {
+		prefix, target, ok := strings.Cut(c.Subject, "! ")
+		switch {
+		case ok && (prefix == "fixup" || prefix == "amend" || prefix == "squash"):
+			for strings.HasPrefix(target, "fixup! ") || strings.HasPrefix(target, "squash! ") || strings.HasPrefix(target, "amend! ") {
+				_, target, _ = strings.Cut(target, "! ")
+			}
+			if i, found := index[target]; found {
+				if prefix == "squash" && c.Body != "" {
+					o[i].Body = strings.TrimSpace(o[i].Body + "\n\n" + c.Body)
+				}
+				o[i].Trailers = append(o[i].Trailers, c.Trailers...)
+				continue
+			}
+			c = ParseCommit(c.SHA, c.Author, target, c.Body)
+		}
+		index[c.Subject] = len(o)
+		o = append(o, c)
+	}
+	return o
+}
+
+// trailers returns the unique trailers from all commits
+func trailers(commits []Commit) []Trailer {
+	var o []Trailer
+	seen := make(map[string]bool)
+	for _, c := range commits {
+		for _, t := range c.Trailers {
+			key := strings.ToLower(t.String())
+			if !seen[key] {
+				seen[key] = true
+				o = append(o, t)
+			}
+		}
+	}
+	return o
+}
+
+// commitGroups is the order and heading of Conventional Commit types when
+// using the "conventional" description format
+var commitGroups = []struct {
+	heading string
+	types   []string
+}{
+	{"Features", []string{"feat"}},
+	{"Fixes", []string{"fix"}},
+	{"Performance", []string{"perf"}},
+	{"Refactoring", []string{"refactor"}},
+	{"Documentation", []string{"docs"}},
+	{"Tests", []string{"test"}},
+	{"Chores", []string{"chore", "build", "ci", "style"}},
+}
+
+// WriteCommitSummary drafts a title and description from commits. The first
+// commit subject is used as the title.
+//
+// The "plain" format lists each commit subject as a bullet followed by its
+// body. The "conventional" format groups bullets by Conventional Commit type.
+// Both formats drop fixup commits and collect trailers into a final section.
+func WriteCommitSummary(w io.Writer, commits []Commit, format string) error {
+	commits = squashFixups(commits)
+	if len(commits) == 0 {
+		return nil
+	}
+	switch format {
+	case "", "plain":
+		for i, c := range commits {
+			if c.Subject == "" {
+				continue
+			}
+			switch i {
+			case 0:
+				fmt.Fprintf(w, "%s\n", c.Subject)
+			case 1:
+				fmt.Fprintf(w, "\n * %s\n", c.Subject)
+			default:
+				fmt.Fprintf(w, " * %s\n", c.Subject)
+			}
+			if c.Body != "" {
+				fmt.Fprintf(w, "%s\n", c.Body)
+			}
+		}
+	case "conventional":
+		fmt.Fprintf(w, "%s\n", commits[0].Subject)
+		if commits[0].Body != "" {
+			fmt.Fprintf(w, "%s\n", commits[0].Body)
+		}
+		// the first commit is the title; group the rest, as plain mode lists them
+		if len(commits) > 1 {
+			writeCommitGroups(w, commits[1:])
+		}
+	default:
+		return fmt.Errorf("unknown description format %q; expected plain or conventional", format)
+	}
+
+	if t := trailers(commits); len(t) > 0 {
+		io.WriteString(w, "\n")
+		for _, t := range t {
+			fmt.Fprintf(w, "%s\n", t)
+		}
+	}
+	return nil
+}
+
+func writeCommitGroups(w io.Writer, commits []Commit) {
+	grouped := make(map[string]bool)
+	bullet := func(c Commit) {
+		grouped[c.SHA] = true
+		var scope, breaking string
+		if c.Scope != "" {
+			scope = fmt.Sprintf("**%s:** ", c.Scope)
+		}
+		if c.Breaking {
+			breaking = " (breaking change)"
+		}
+		fmt.Fprintf(w, " * %s%s%s\n", scope, c.Description, breaking)
+	}
+	for _, g := range commitGroups {
+		var heading bool
+		for _, c := range commits {
+			if !contains(g.types, c.Type) {
+				continue
+			}
+			if !heading {
+				fmt.Fprintf(w, "\n### %s\n\n", g.heading)
+				heading = true
+			}
+			bullet(c)
+		}
+	}
+	var heading bool
+	for _, c := range commits {
+		if grouped[c.SHA] {
+			continue
+		}
+		if !heading {
+			fmt.Fprintf(w, "\n### Other Changes\n\n")
+			heading = true
+		}
+		bullet(c)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseCommit(t *testing.T) {
+	type testCase struct {
+		subject, body string
+		typ, scope    string
+		breaking      bool
+		outBody       string
+		trailers      []string
+	}
+	tests := []testCase{
+		{"feat(api): add retries", "Retry failed requests.\n\nCo-authored-by: A <a@example.com>\nFixes #12", "feat", "api", false, "Retry failed requests.", []string{"Co-authored-by: A <a@example.com>", "Fixes #12"}},
+		{"fix!: drop support", "", "fix", "", true, "", nil},
+		{"Update README", "Some text: with a colon\nand more", "", "", false, "Some text: with a colon\nand more", nil},
+		{"chore: bump", "closes jehiah/git-open-pull#3", "chore", "", false, "", []string{"closes jehiah/git-open-pull#3"}},
+		{"refactor: x", "BREAKING CHANGE: removed flag", "refactor", "", true, "", []string{"BREAKING CHANGE: removed flag"}},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			c := ParseCommit("sha", "author", tc.subject, tc.body)
+			if c.Type != tc.typ || c.Scope != tc.scope || c.Breaking != tc.breaking || c.Body != tc.outBody {
+				t.Errorf("got %q %q %v %q", c.Type, c.Scope, c.Breaking, c.Body)
+			}
+			var trailers []string
+			for _, t := range c.Trailers {
+				trailers = append(trailers, t.String())
+			}
+			if strings.Join(trailers, "|") != strings.Join(tc.trailers, "|") {
+				t.Errorf("got trailers %q expected %q", trailers, tc.trailers)
+			}
+		})
+	}
+}
+
+func TestWriteCommitSummary(t *testing.T) {
+	base := fixtureRepo(t,
+		"feat(api): add retries\n\nRetry failed API requests.\n\nCo-authored-by: B <b@example.com>",
+		"fix: handle timeouts\n\nFixes #12",
+		"fixup! feat(api): add retries",
+		"docs: describe retries\n\nCo-authored-by: B <b@example.com>",
+		"Tidy up",
+	)
+	commits, err := LoadCommits(context.Background(), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 5 || commits[0].Author != "Author <author@example.com>" {
+		t.Fatalf("unexpected commits %#v", commits)
+	}
+
+	type testCase struct {
+		format   string
+		expected string
+	}
+	tests := []testCase{
+		{"plain", `feat(api): add retries
+Retry failed API requests.
+
+ * fix: handle timeouts
+ * docs: describe retries
+ * Tidy up
+
+Co-authored-by: B <b@example.com>
+Fixes #12
+`},
+		{"conventional", `feat(api): add retries
+Retry failed API requests.
+
+### Fixes
+
+ * handle timeouts
+
+### Documentation
+
+ * describe retries
+
+### Other Changes
+
+ * Tidy up
+
+Co-authored-by: B <b@example.com>
+Fixes #12
+`},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := WriteCommitSummary(&buf, commits, tc.format); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("%s format got\n%s\nexpected\n%s", tc.format, buf.String(), tc.expected)
+		}
+		// the commit used for the title is not listed again in the body
+		if n := strings.Count(buf.String(), "add retries"); n != 1 {
+			t.Errorf("%s format lists the title commit %d times", tc.format, n)
+		}
+
+		// the drafted description is submitted as is, including its headings
+		writeTemplateFooter(&buf, "", []string{"bug"}, nil)
+		title, description, _, err := ParseTemplate(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedTitle, expectedDescription, _ := strings.Cut(tc.expected, "\n")
+		if title != expectedTitle || description != strings.TrimSpace(expectedDescription) {
+			t.Errorf("%s format parsed as %q %q", tc.format, title, description)
+		}
+	}
+}
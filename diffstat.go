@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileStat is the number of lines added and deleted in a changed file
+type FileStat struct {
+	Path    string
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+// DiffStat returns the files changed between mergeBase and HEAD
+func DiffStat(ctx context.Context, mergeBase string) ([]FileStat, error) {
+	body, err := RunGit(ctx, "diff", "--numstat", "--no-renames", mergeBase, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return parseNumstat(string(body)), nil
+}
+
+// parseNumstat parses the output of `git diff --numstat`
+func parseNumstat(s string) []FileStat {
+	var files []FileStat
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		chunks := strings.SplitN(line, "\t", 3)
+		if len(chunks) != 3 {
+			continue
+		}
+		f := FileStat{Path: chunks[2]}
+		if chunks[0] == "-" {
+			f.Binary = true
+		} else {
+			f.Added, _ = strconv.Atoi(chunks[0])
+			f.Deleted, _ = strconv.Atoi(chunks[1])
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// fileGroup is a set of changed files grouped by directory or owner
+type fileGroup struct {
+	Name  string
+	Files []FileStat
+}
+
+func (g fileGroup) totals() (added, deleted int) {
+	for _, f := range g.Files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	return
+}
+
+// groupFiles groups files by "directory" (the top level directory), by
+// "codeowners" (the owners from a CODEOWNERS file) or not at all ("none")
+func groupFiles(files []FileStat, by string, owners []codeOwnersRule) ([]fileGroup, error) {
+	var key func(f FileStat) string
+	switch by {
+	case "", "none":
+		return []fileGroup{{Files: files}}, nil
+	case "directory":
+		key = func(f FileStat) string {
+			if dir, _, ok := strings.Cut(f.Path, "/"); ok {
+				return dir + "/"
+			}
+			return "/"
+		}
+	case "codeowners":
+		key = func(f FileStat) string {
+			if o := matchCodeOwners(owners, f.Path); len(o) > 0 {
+				return strings.Join(o, " ")
+			}
+			return "(no owner)"
+		}
+	default:
+		return nil, fmt.Errorf("unknown diffstat grouping %q; expected none, directory or codeowners", by)
+	}
+	index := make(map[string]int)
+	var groups []fileGroup
+	for _, f := range files {
+		k := key(f)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, fileGroup{Name: k})
+		}
+		groups[i].Files = append(groups[i].Files, f)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+func formatLines(f FileStat) string {
+	if f.Binary {
+		return "binary"
+	}
+	return fmt.Sprintf("+%d -%d", f.Added, f.Deleted)
+}
+
+// WriteDiffstat writes a summary of changed files. The "comment" style writes
+// lines starting with '#' that are shown in the editor but not included in the
+// description; the "details" style writes a collapsible <details> section.
+func WriteDiffstat(w io.Writer, files []FileStat, style string, groups []fileGroup) error {
+	if len(files) == 0 {
+		return nil
+	}
+	total := fileGroup{Files: files}
+	added, deleted := total.totals()
+	summary := fmt.Sprintf("%d files changed, +%d -%d", len(files), added, deleted)
+	if len(files) == 1 {
+		summary = fmt.Sprintf("1 file changed, +%d -%d", added, deleted)
+	}
+
+	switch style {
+	case "off", "":
+		return nil
+	case "comment":
+		fmt.Fprintf(w, "\n# %s\n", summary)
+		for _, g := range groups {
+			indent := "#   "
+			if g.Name != "" {
+				a, d := g.totals()
+				fmt.Fprintf(w, "#   %s (+%d -%d)\n", g.Name, a, d)
+				indent = "#     "
+			}
+			for _, f := range g.Files {
+				fmt.Fprintf(w, "%s%s %s\n", indent, f.Path, formatLines(f))
+			}
+		}
+	case "details":
+		fmt.Fprintf(w, "\n<details>\n<summary>%s</summary>\n\n", summary)
+		for _, g := range groups {
+			if g.Name != "" {
+				a, d := g.totals()
+				fmt.Fprintf(w, "**%s** (+%d -%d)\n\n", g.Name, a, d)
+			}
+			for _, f := range g.Files {
+				fmt.Fprintf(w, " * `%s` %s\n", f.Path, formatLines(f))
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "</details>\n")
+	default:
+		return fmt.Errorf("unknown diffstat style %q; expected off, comment or details", style)
+	}
+	return nil
+}
+
+// codeOwnersRule is a pattern and its owners from a CODEOWNERS file
+type codeOwnersRule struct {
+	Pattern string
+	Owners  []string
+}
+
+// ReadCodeOwners reads the CODEOWNERS file from the repository root, .github/
+// or docs/. It returns no rules if there is no CODEOWNERS file.
+func ReadCodeOwners(root string) ([]codeOwnersRule, error) {
+	for _, p := range []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"} {
+		f, err := os.Open(filepath.Join(root, p))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		var rules []codeOwnersRule
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			rules = append(rules, codeOwnersRule{Pattern: fields[0], Owners: fields[1:]})
+		}
+		return rules, scanner.Err()
+	}
+	return nil, nil
+}
+
+// matchCodeOwners returns the owners of file. As in CODEOWNERS the last
+// matching rule wins.
+func matchCodeOwners(rules []codeOwnersRule, file string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matchCodeOwnersPattern(rules[i].Pattern, file) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// matchCodeOwnersPattern matches a gitignore style CODEOWNERS pattern. A
+// pattern matches a file directly or any directory containing it. Patterns
+// containing a slash (other than a trailing one) are anchored to the
+// repository root; others match at any depth.
+func matchCodeOwnersPattern(pattern, file string) bool {
+	if pattern == "*" {
+		return true
+	}
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/**")
+	if strings.HasPrefix(pattern, "**/") {
+		pattern = pattern[3:]
+	} else if strings.Contains(pattern, "/") {
+		// anchored; match the path or one of its parent directories
+		pattern = strings.TrimPrefix(pattern, "/")
+		parts := strings.Split(file, "/")
+		for i := 1; i <= len(parts); i++ {
+			if dirOnly && i == len(parts) {
+				break
+			}
+			if ok, _ := path.Match(pattern, strings.Join(parts[:i], "/")); ok {
+				return true
+			}
+		}
+		return false
+	}
+	parts := strings.Split(file, "/")
+	for i, p := range parts {
+		if dirOnly && i == len(parts)-1 {
+			break
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
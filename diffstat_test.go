@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMatchCodeOwnersPattern(t *testing.T) {
+	type testCase struct {
+		pattern, file string
+		match         bool
+	}
+	tests := []testCase{
+		{"*", "main.go", true},
+		{"*.go", "internal/input/ask.go", true},
+		{"*.go", "README.md", false},
+		{"/docs/", "docs/index.md", true},
+		{"/docs/", "api/docs/index.md", false},
+		{"docs/", "api/docs/index.md", true},
+		{"docs/", "docs", false},
+		{"internal/input", "internal/input/ask.go", true},
+		{"/internal/*.go", "internal/input/ask.go", false},
+		{"/internal/input/*.go", "internal/input/ask.go", true},
+		{"**/input", "internal/input/ask.go", true},
+		{"internal/**", "internal/input/ask.go", true},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			if got := matchCodeOwnersPattern(tc.pattern, tc.file); got != tc.match {
+				t.Errorf("got %v expected %v for %q %q", got, tc.match, tc.pattern, tc.file)
+			}
+		})
+	}
+}
+
+func TestWriteDiffstat(t *testing.T) {
+	files := parseNumstat("10\t2\tinternal/input/ask.go\n3\t0\tREADME.md\n-\t-\tdocs/logo.png\n1\t1\tinternal/input/reader.go\n")
+	owners := []codeOwnersRule{
+		{"*", []string{"@jehiah"}},
+		{"/internal/", []string{"@acme/core"}},
+	}
+
+	type testCase struct {
+		style, group string
+		expected     string
+	}
+	tests := []testCase{
+		{"comment", "none", `
+# 4 files changed, +14 -3
+#   internal/input/ask.go +10 -2
+#   README.md +3 -0
+#   docs/logo.png binary
+#   internal/input/reader.go +1 -1
+`},
+		{"details", "directory", `
+<details>
+<summary>4 files changed, +14 -3</summary>
+
+**/** (+3 -0)
+
+ * ` + "`README.md`" + ` +3 -0
+
+**docs/** (+0 -0)
+
+ * ` + "`docs/logo.png`" + ` binary
+
+**internal/** (+11 -3)
+
+ * ` + "`internal/input/ask.go`" + ` +10 -2
+ * ` + "`internal/input/reader.go`" + ` +1 -1
+
+</details>
+`},
+		{"comment", "codeowners", `
+# 4 files changed, +14 -3
+#   @acme/core (+11 -3)
+#     internal/input/ask.go +10 -2
+#     internal/input/reader.go +1 -1
+#   @jehiah (+3 -0)
+#     README.md +3 -0
+#     docs/logo.png binary
+`},
+		{"off", "none", ""},
+	}
+	for _, tc := range tests {
+		groups, err := groupFiles(files, tc.group, owners)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := WriteDiffstat(&buf, files, tc.style, groups); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("%s %s got\n%s\nexpected\n%s", tc.style, tc.group, buf.String(), tc.expected)
+		}
+	}
+}
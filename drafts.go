@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// DraftPath returns the path a draft description for branch is saved to:
+// .git/git-open-pull/drafts/<branch>.md
+func DraftPath(ctx context.Context, branch string) (string, error) {
+	body, err := RunGit(ctx, "rev-parse", "--git-path", "git-open-pull/drafts")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(strings.TrimSpace(string(body)), filepath.FromSlash(branch)+".md"), nil
+}
+
+// SaveDraft saves a description so it is not lost when creating an issue fails
+func SaveDraft(ctx context.Context, branch string, content []byte) error {
+	filename, err := DraftPath(ctx, branch)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, content, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "saved draft description to %s\n", filename)
+	return nil
+}
+
+// SaveDraftFile saves the contents of filename as the draft for the current branch
+func SaveDraftFile(ctx context.Context, filename string) {
+	content, err := os.ReadFile(filename)
+	if err != nil || len(strings.TrimSpace(string(content))) == 0 {
+		return
+	}
+	branch, err := GitFeatureBranch(ctx)
+	if err != nil {
+		return
+	}
+	if err := SaveDraft(ctx, branch, content); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving draft %s\n", err)
+	}
+}
+
+// formatDraft formats an issue request in the template format parsed by ParseTemplate
+func formatDraft(ir *github.IssueRequest) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", ir.GetTitle())
+	if ir.GetBody() != "" {
+		fmt.Fprintf(&b, "%s\n\n", ir.GetBody())
+	}
+	fmt.Fprintf(&b, "%s\n", templateScissors)
+	if ir.Labels != nil {
+		for _, l := range *ir.Labels {
+			fmt.Fprintf(&b, "Label: %s\n", l)
+		}
+	}
+	return []byte(b.String())
+}
+
+// LoadDraft returns the saved draft for branch and when it was saved, or nil
+// if there is no draft
+func LoadDraft(ctx context.Context, branch string) ([]byte, time.Time, error) {
+	filename, err := DraftPath(ctx, branch)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fi, err := os.Stat(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	content, err := os.ReadFile(filename)
+	return content, fi.ModTime(), err
+}
+
+// DiscardDraft removes the saved draft for branch if there is one
+func DiscardDraft(ctx context.Context, branch string) error {
+	filename, err := DraftPath(ctx, branch)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestDrafts(t *testing.T) {
+	fixtureRepo(t, "first")
+	ctx := context.Background()
+
+	if content, _, err := LoadDraft(ctx, "main"); err != nil || content != nil {
+		t.Fatalf("got %q %v expected no draft", content, err)
+	}
+	draft := formatDraft(&github.IssueRequest{Title: github.String("title"), Body: github.String("body")})
+	if err := SaveDraft(ctx, "main", draft); err != nil {
+		t.Fatal(err)
+	}
+	if content, _, err := LoadDraft(ctx, "main"); err != nil || !bytes.Equal(content, draft) {
+		t.Fatalf("got %q %v expected %q", content, err, draft)
+	}
+	filename, err := DraftPath(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DiscardDraft(ctx, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed; got %v", filename, err)
+	}
+	// discarding a missing draft is not an error
+	if err := DiscardDraft(ctx, "main"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// runEdit implements `git-open-pull edit [number]`
+func runEdit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	allowSecrets := fs.Bool("allow-secrets", false, "update the pull request even if the title or description appears to contain credentials")
+	fs.Parse(args)
+
+	settings, client, err := commandSetup(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	if *allowSecrets && settings.SecretScan != "off" {
+		settings.SecretScan = "warn"
+	}
+	pr, err := pullRequestFromArgs(ctx, client, settings, fs.Args())
+	if err != nil {
+		return err
+	}
+	labels, err := Labels(ctx, client, settings)
+	if err != nil {
+		return err
+	}
+
+	var current []string
+	labelSet := make(map[string]bool)
+	for _, l := range pr.Labels {
+		current = append(current, l.GetName())
+		if !labelSet[l.GetName()] && !contains(labels, l.GetName()) {
+			labels = append(labels, l.GetName())
+		}
+		labelSet[l.GetName()] = true
+	}
+
+	tempFile, err := os.CreateTemp("", "git-open-pull")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	fmt.Fprintf(tempFile, "%s\n\n", pr.GetTitle())
+	if body := normalizeBody(pr.GetBody()); body != "" {
+		fmt.Fprintf(tempFile, "%s\n", body)
+	}
+	writeTemplateFooter(tempFile, "", labels, labelSet)
+	tempFile.Sync()
+	tempFile.Close()
+
+	hc := &HookContext{Labels: current, Issue: pr.GetNumber(), PullRequest: pr.GetNumber()}
+	title, description, selectedLabels, err := EditAndLintTemplate(ctx, settings, hc, tempFile.Name())
+	if err != nil {
+		return err
+	}
+
+	req, changed := editRequest(pr, current, title, description, selectedLabels)
+	if len(changed) == 0 {
+		fmt.Println("no changes")
+		return nil
+	}
+	_, _, err = client.Issues.Edit(ctx, settings.BaseAccount, settings.BaseRepo, pr.GetNumber(), req)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("updated %s of #%d\n%s\n", strings.Join(changed, ", "), pr.GetNumber(), pr.GetHTMLURL())
+	return nil
+}
+
+// editRequest returns a request updating only the title, description and
+// labels that differ from pr, and the names of the fields that changed
+func editRequest(pr *github.PullRequest, current []string, title, description string, labels []string) (*github.IssueRequest, []string) {
+	var req github.IssueRequest
+	var changed []string
+	if title != pr.GetTitle() {
+		req.Title = &title
+		changed = append(changed, "title")
+	}
+	if description != normalizeBody(pr.GetBody()) {
+		req.Body = &description
+		changed = append(changed, "description")
+	}
+	if !sameSet(current, labels) {
+		if labels == nil {
+			labels = []string{}
+		}
+		req.Labels = &labels
+		changed = append(changed, "labels")
+	}
+	return &req, changed
+}
+
+// normalizeBody converts a pull request body to the form ParseTemplate
+// returns: CRLF line endings (from the GitHub web editor) and trailing
+// whitespace on each line are removed.
+func normalizeBody(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSet reports if a and b contain the same values, ignoring order and duplicates
+func sameSet(a, b []string) bool {
+	dedupe := func(l []string) string {
+		m := make(map[string]bool)
+		var o []string
+		for _, v := range l {
+			if !m[v] {
+				m[v] = true
+				o = append(o, v)
+			}
+		}
+		sort.Strings(o)
+		return strings.Join(o, "\x00")
+	}
+	return dedupe(a) == dedupe(b)
+}
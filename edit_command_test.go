@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestEditRequest(t *testing.T) {
+	pr := &github.PullRequest{
+		Title: github.String("Add retries"),
+		Body:  github.String("Retry failed requests.  \r\n\r\n## Testing\r\n\r\nran the unit tests\r\n"),
+	}
+	// write the pull request to a template and parse it unedited
+	filename := filepath.Join(t.TempDir(), "template")
+	var b bytes.Buffer
+	b.WriteString(pr.GetTitle() + "\n\n" + normalizeBody(pr.GetBody()) + "\n")
+	writeTemplateFooter(&b, "", []string{"bug"}, map[string]bool{"bug": true})
+	if err := os.WriteFile(filename, b.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	title, description, labels, err := ParseTemplateFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req, changed := editRequest(pr, []string{"bug"}, title, description, labels); len(changed) != 0 {
+		t.Errorf("got changes %q for an unedited pull request: %v", changed, req)
+	}
+
+	req, changed := editRequest(pr, []string{"bug"}, title, description+"\n\nand the integration tests", nil)
+	if expected := []string{"description", "labels"}; !reflect.DeepEqual(changed, expected) {
+		t.Errorf("got changes %q expected %q", changed, expected)
+	}
+	if req.Title != nil || req.Body == nil || req.Labels == nil || len(*req.Labels) != 0 {
+		t.Errorf("unexpected request %v", req)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/jehiah/git-open-pull/internal/input"
+)
+
+// isNotFound reports if err is a 404 response from the GitHub API
+func isNotFound(err error) bool {
+	var ge *github.ErrorResponse
+	return errors.As(err, &ge) && ge.Response != nil && ge.Response.StatusCode == http.StatusNotFound
+}
+
+// DetectSameRepo reports if branches should be pushed directly to
+// settings.BaseAccount/settings.BaseRepo instead of a fork. With
+// gitOpenPull.sameRepo=auto this is the case when settings.User is
+// settings.BaseAccount, or when settings.User has no fork but can push to the
+// upstream repository.
+func DetectSameRepo(ctx context.Context, client *github.Client, settings *Settings) (bool, error) {
+	switch settings.SameRepo {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "", "auto":
+	default:
+		return false, fmt.Errorf("invalid gitOpenPull.sameRepo %q; expected true, false or auto", settings.SameRepo)
+	}
+	if strings.EqualFold(settings.User, settings.BaseAccount) {
+		return true, nil
+	}
+	_, _, err := client.Repositories.Get(ctx, settings.User, settings.BaseRepo)
+	switch {
+	case err == nil:
+		return false, nil
+	case !isNotFound(err):
+		return false, err
+	}
+	upstream, _, err := client.Repositories.Get(ctx, settings.BaseAccount, settings.BaseRepo)
+	if err != nil {
+		return false, err
+	}
+	return upstream.GetPermissions()["push"], nil
+}
+
+// PushOwner returns the account branches are pushed to (and the name of the
+// git remote for it): settings.BaseAccount in same repository mode, otherwise
+// settings.User
+func PushOwner(settings *Settings, sameRepo bool) string {
+	if sameRepo {
+		return settings.BaseAccount
+	}
+	return settings.User
+}
+
+// PullRequestHead returns the head reference for a pull request from branch
+func PullRequestHead(settings *Settings, sameRepo bool, branch string) string {
+	if sameRepo {
+		return branch
+	}
+	return fmt.Sprintf("%s:%s", settings.User, branch)
+}
+
+// EnsureFork checks that the fork settings.User/settings.BaseRepo exists,
+// creating it (when create is set, or after confirmation in interactive mode)
+// and waiting until it is ready. It returns the name of the git remote for the
+// fork, adding one named settings.User if no remote refers to it.
+func EnsureFork(ctx context.Context, client *github.Client, settings *Settings, create, interactive bool) (string, error) {
+	fork, _, err := client.Repositories.Get(ctx, settings.User, settings.BaseRepo)
+	switch {
+	case isNotFound(err):
+		if !create && interactive {
+			yn, err := input.Ask(fmt.Sprintf("fork %s/%s does not exist. Create it from %s/%s? [Y/n]", settings.User, settings.BaseRepo, settings.BaseAccount, settings.BaseRepo), "")
+			if err != nil {
+				return "", err
+			}
+			create = yn == "" || strings.ToLower(yn) == "y"
+		}
+		if !create {
+			return "", fmt.Errorf("fork %s/%s does not exist; re-run with --fork to create it", settings.User, settings.BaseRepo)
+		}
+		fork, err = CreateFork(ctx, client, settings)
+		if err != nil {
+			return "", err
+		}
+	case err != nil:
+		return "", err
+	}
+	return remoteFor(ctx, settings, settings.User, fork)
+}
+
+// UpstreamRemote returns the name of the git remote for
+// settings.BaseAccount/settings.BaseRepo, which branches are pushed to in same
+// repository mode, adding one named settings.BaseAccount if no remote refers
+// to it.
+func UpstreamRemote(ctx context.Context, client *github.Client, settings *Settings) (string, error) {
+	upstream, _, err := client.Repositories.Get(ctx, settings.BaseAccount, settings.BaseRepo)
+	if err != nil {
+		return "", err
+	}
+	return remoteFor(ctx, settings, settings.BaseAccount, upstream)
+}
+
+// remoteFor returns the name of the git remote for repo. An existing remote
+// may refer to it under another name (i.e. origin); otherwise the remote
+// called name is used, and added if it does not exist.
+func remoteFor(ctx context.Context, settings *Settings, name string, repo *github.Repository) (string, error) {
+	if found := FindRemote(ctx, repo.GetHTMLURL()); found != "" {
+		return found, nil
+	}
+	if _, err := RunGit(ctx, "remote", "get-url", name); err == nil {
+		return name, nil
+	}
+	return name, addRemote(ctx, settings, name, repo)
+}
+
+// CreateFork forks settings.BaseAccount/settings.BaseRepo and waits for
+// GitHub to finish creating it
+func CreateFork(ctx context.Context, client *github.Client, settings *Settings) (*github.Repository, error) {
+	fmt.Printf("creating fork of %s/%s\n", settings.BaseAccount, settings.BaseRepo)
+	_, _, err := client.Repositories.CreateFork(ctx, settings.BaseAccount, settings.BaseRepo, &github.RepositoryCreateForkOptions{})
+	var accepted *github.AcceptedError
+	if err != nil && !errors.As(err, &accepted) {
+		return nil, err
+	}
+
+	// Forking happens asynchronously; the fork is usable once its branches can be listed
+	for i := 0; i < 30; i++ {
+		time.Sleep(2 * time.Second)
+		fork, _, err := client.Repositories.Get(ctx, settings.User, settings.BaseRepo)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		branches, _, err := client.Repositories.ListBranches(ctx, settings.User, settings.BaseRepo, &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 1}})
+		if err == nil && len(branches) > 0 {
+			fmt.Printf("created fork %s\n", fork.GetHTMLURL())
+			return fork, nil
+		}
+	}
+	return nil, fmt.Errorf("timed out waiting for fork %s/%s to be created", settings.User, settings.BaseRepo)
+}
+
+// ensureRemote returns the name of a git remote for repo, adding one named
+// after the repository owner if needed
+func ensureRemote(ctx context.Context, settings *Settings, repo *github.Repository) (string, error) {
+	if name := FindRemote(ctx, repo.GetHTMLURL()); name != "" {
+		return name, nil
+	}
+	name := repo.GetOwner().GetLogin()
+	if _, err := RunGit(ctx, "remote", "get-url", name); err == nil {
+		return "", fmt.Errorf("remote %s exists but does not refer to %s", name, repo.GetFullName())
+	}
+	return name, addRemote(ctx, settings, name, repo)
+}
+
+// addRemote adds a git remote for repo, matching the URL scheme (ssh or https)
+// of the settings.BaseAccount remote
+func addRemote(ctx context.Context, settings *Settings, name string, repo *github.Repository) error {
+	u := repo.GetSSHURL()
+	if base, err := RunGit(ctx, "remote", "get-url", settings.BaseAccount); err == nil && strings.HasPrefix(string(base), "https://") {
+		u = repo.GetCloneURL()
+	}
+	fmt.Printf("adding remote %s %s\n", name, u)
+	_, err := RunGit(ctx, "remote", "add", name, u)
+	return err
+}
+
+// FindRemote returns the name of the git remote whose URL refers to the same
+// repository as repoURL, or an empty string
+func FindRemote(ctx context.Context, repoURL string) string {
+	body, err := RunGit(ctx, "remote")
+	if err != nil {
+		return ""
+	}
+	want := remoteHostPath(repoURL)
+	for _, name := range strings.Fields(string(body)) {
+		u, err := RunGit(ctx, "remote", "get-url", name)
+		if err == nil && strings.EqualFold(remoteHostPath(strings.TrimSpace(string(u))), want) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestEnsureForkRemote(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/jehiah/widgets" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"full_name": "jehiah/widgets", "html_url": "https://github.com/jehiah/widgets", "ssh_url": "git@github.com:jehiah/widgets.git", "clone_url": "https://github.com/jehiah/widgets.git", "owner": {"login": "jehiah"}}`))
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	type testCase struct {
+		name    string
+		remotes [][2]string
+		remote  string
+		url     string
+	}
+	tests := []testCase{
+		{"other name", [][2]string{{"acme", "git@github.com:acme/widgets.git"}, {"origin", "git@github.com:jehiah/widgets.git"}}, "origin", "git@github.com:jehiah/widgets.git"},
+		{"user", [][2]string{{"jehiah", "https://github.com/jehiah/widgets"}}, "jehiah", "https://github.com/jehiah/widgets"},
+		{"added", [][2]string{{"acme", "git@github.com:acme/widgets.git"}}, "jehiah", "git@github.com:jehiah/widgets.git"},
+		{"added https", [][2]string{{"acme", "https://github.com/acme/widgets.git"}}, "jehiah", "https://github.com/jehiah/widgets.git"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixtureRepo(t)
+			ctx := context.Background()
+			for _, r := range tc.remotes {
+				if _, err := RunGit(ctx, "remote", "add", r[0], r[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			settings := &Settings{User: "jehiah", BaseAccount: "acme", BaseRepo: "widgets"}
+			remote, err := EnsureFork(ctx, client, settings, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if remote != tc.remote {
+				t.Errorf("got remote %q expected %q", remote, tc.remote)
+			}
+			u, err := RunGit(ctx, "remote", "get-url", remote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(u)); got != tc.url {
+				t.Errorf("got url %q expected %q", got, tc.url)
+			}
+		})
+	}
+}
+
+func TestUpstreamRemote(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/widgets" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"full_name": "acme/widgets", "html_url": "https://github.com/acme/widgets", "ssh_url": "git@github.com:acme/widgets.git", "clone_url": "https://github.com/acme/widgets.git", "owner": {"login": "acme"}}`))
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	type testCase struct {
+		name    string
+		remotes [][2]string
+		remote  string
+	}
+	tests := []testCase{
+		{"origin", [][2]string{{"origin", "git@github.com:acme/widgets.git"}}, "origin"},
+		{"base account", [][2]string{{"acme", "https://github.com/acme/widgets"}}, "acme"},
+		{"added", nil, "acme"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixtureRepo(t)
+			ctx := context.Background()
+			for _, r := range tc.remotes {
+				if _, err := RunGit(ctx, "remote", "add", r[0], r[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			settings := &Settings{User: "jehiah", BaseAccount: "acme", BaseRepo: "widgets"}
+			remote, err := UpstreamRemote(ctx, client, settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if remote != tc.remote {
+				t.Errorf("got remote %q expected %q", remote, tc.remote)
+			}
+		})
+	}
+}
+
+func TestPushOwner(t *testing.T) {
+	type testCase struct {
+		sameRepo bool
+		owner    string
+		head     string
+	}
+	tests := []testCase{
+		{false, "jehiah", "jehiah:feature_12"},
+		{true, "acme", "feature_12"},
+	}
+	settings := &Settings{User: "jehiah", BaseAccount: "acme", BaseRepo: "widgets"}
+	for _, tc := range tests {
+		if got := PushOwner(settings, tc.sameRepo); got != tc.owner {
+			t.Errorf("PushOwner(%v) got %q expected %q", tc.sameRepo, got, tc.owner)
+		}
+		if got := PullRequestHead(settings, tc.sameRepo, "feature_12"); got != tc.head {
+			t.Errorf("PullRequestHead(%v) got %q expected %q", tc.sameRepo, got, tc.head)
+		}
+	}
+}
@@ -3,11 +3,11 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
@@ -22,6 +22,15 @@ import (
 //go:embed SKILL.md
 var skillDoc string
 
+// stringList is a flag that can be passed multiple times
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
 func RenameBranch(ctx context.Context, branch string, issueNumber int) (string, error) {
 	branch = fmt.Sprintf("%s_%d", branch, issueNumber)
 	_, err := RunGit(ctx, "branch", "-m", branch)
@@ -31,6 +40,53 @@ func RenameBranch(ctx context.Context, branch string, issueNumber int) (string,
 	return branch, nil
 }
 
+// StaleBranch returns the remote and branch left behind if branch, which was
+// pushed to pushRemote, is renamed. That is the branch's upstream when it
+// tracks a branch of the same name on pushRemote, or the branch of that name
+// on pushRemote when it has no upstream. The base branch and the remote's
+// default branch are never returned.
+func StaleBranch(ctx context.Context, settings *Settings, pushRemote, branch string) (string, string) {
+	remote, merge := UpstreamBranch(ctx, branch)
+	switch {
+	case remote == "":
+		if RemoteBranchSHA(ctx, pushRemote, branch) == "" {
+			return "", ""
+		}
+	case remote != pushRemote || merge != "refs/heads/"+branch:
+		// i.e. a branch started from origin/main or a local branch (remote ".")
+		return "", ""
+	}
+	if branch == settings.BaseBranch || branch == RemoteDefaultBranch(ctx, pushRemote) {
+		return "", ""
+	}
+	return pushRemote, branch
+}
+
+// RemoveStaleBranch deletes a remote branch left behind when a branch was pushed
+// before being renamed (see StaleBranch). Without confirmation the remote
+// branch is only removed when all of its commits are included in HEAD.
+func RemoveStaleBranch(ctx context.Context, remote, branch string, interactive bool) error {
+	sha := RemoteBranchSHA(ctx, remote, branch)
+	if sha == "" {
+		return nil
+	}
+	if interactive {
+		yn, err := input.Ask(fmt.Sprintf("delete branch %s from %s which was pushed before renaming [y/N]", branch, remote), "")
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(yn) != "y" {
+			return nil
+		}
+	} else if !IsAncestor(ctx, sha) {
+		fmt.Printf("not deleting branch %s from %s: it has commits that were not pushed under the new name\n", branch, remote)
+		return nil
+	}
+	fmt.Printf("deleting branch %s from %s\n", branch, remote)
+	_, err := RunGit(ctx, "push", remote, "--delete", branch)
+	return err
+}
+
 func SetupClient(ctx context.Context, s *Settings) *github.Client {
 	if s == nil {
 		panic("missing settings")
@@ -45,7 +101,7 @@ func SetupClient(ctx context.Context, s *Settings) *github.Client {
 }
 
 // GetIssueNumber prompts to create a new issue, or confirmation of auto-detected issue number
-func GetIssueNumber(ctx context.Context, client *github.Client, settings *Settings, detected int, interactive bool, title, description string, labels []string) (int, error) {
+func GetIssueNumber(ctx context.Context, client *github.Client, settings *Settings, hc *HookContext, detected int, interactive bool, title, description string, labels []string) (int, error) {
 	var issue int
 	if detected == 0 {
 		var err error
@@ -58,7 +114,7 @@ func GetIssueNumber(ctx context.Context, client *github.Client, settings *Settin
 		}
 		switch n {
 		case "", "c", "C":
-			return NewIssue(ctx, client, settings, interactive, title, description, labels)
+			return NewIssue(ctx, client, settings, hc, interactive, title, description, labels)
 		default:
 			return strconv.Atoi(n)
 		}
@@ -67,7 +123,7 @@ func GetIssueNumber(ctx context.Context, client *github.Client, settings *Settin
 	if interactive {
 		n, err := input.Ask(fmt.Sprintf("issue number [%d]", detected), "")
 		if err != nil {
-			log.Fatal(err)
+			return 0, err
 		}
 		if n == "" {
 			return detected, nil
@@ -83,7 +139,10 @@ func printUsage(settings *Settings) {
 	fmt.Fprintln(out, "git-open-pull creates an issue, renames the local branch to include that issue number, pushes the renamed branch and finally converts the issue into a pull request against the renamed branch.")
 	fmt.Fprintln(out, "Functionally similar to 'gh pr create'.")
 	if settings != nil && settings.User != "" && settings.BaseAccount != "" && settings.BaseRepo != "" {
-		fmt.Fprintf(out, "By default, code is pushed to %s/%s and the pull request targets %s/%s branch %s.\n", settings.User, settings.BaseRepo, settings.BaseAccount, settings.BaseRepo, settings.BaseBranch)
+		fmt.Fprintf(out, "By default, code is pushed to %s/%s and the pull request targets %s/%s branch %s.\n", PushOwner(settings, settings.SameRepo == "true"), settings.BaseRepo, settings.BaseAccount, settings.BaseRepo, settings.BaseBranch)
+	}
+	if settings != nil && settings.Profile != "" {
+		fmt.Fprintf(out, "Using profile %q (%s).\n", settings.Profile, settings.Source("profile"))
 	}
 	fmt.Fprintln(out)
 
@@ -99,22 +158,34 @@ func printUsage(settings *Settings) {
 
 	if agentdetection.IsAgent() {
 		fmt.Fprintln(out, "Agent Hint: run --list-labels first to inspect the valid repository labels before passing --labels.")
-		fmt.Fprintln(out, "Do not push code prior to running, as the branch will be renamed to include the issue number.")
+		fmt.Fprintln(out, "Pushing code prior to running is not needed; a branch pushed before it is renamed to include the issue number is pushed under the new name and the old remote branch is removed.")
 		fmt.Fprintln(out, "Run --skill for full agent usage documentation, or redirect to a file: git-open-pull --skill > SKILL.md")
 		fmt.Fprintln(out)
 	}
+	printSubcommands(out)
 	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
 	flag.PrintDefaults()
 }
 
 func main() {
 	ctx := context.Background()
-	preSettings, _ := readSettingsConfig(ctx)
+	preSettings, _ := readSettingsConfig(ctx, "")
 
 	flag.Usage = func() {
 		printUsage(preSettings)
 	}
 
+	if len(os.Args) > 1 {
+		if cmd, ok := lookupSubcommand(os.Args[1]); ok {
+			if err := cmd.Run(ctx, os.Args[2:]); err != nil {
+				// subcommands load their own settings; on-error hooks use the defaults
+				RunErrorHooks(ctx, preSettings, nil, err)
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
 	description := flag.String("description-file", "", "Path to PR description file")
 	listLabels := flag.Bool("list-labels", false, "List available issue labels and exit")
 	skill := flag.Bool("skill", false, "Print agent skill documentation and exit")
@@ -123,6 +194,16 @@ func main() {
 	interactive := flag.Bool("interactive", true, "Toggles interactive mode")
 	version := flag.Bool("version", false, "Prints current version")
 	draft := flag.Bool("draft", true, "Open PR in draft mode (non-interactive only)")
+	dryRun := flag.Bool("dry-run", false, "Run preflight checks and print what would be done without creating anything")
+	forceWithLease := flag.Bool("force-with-lease", false, "Push with --force-with-lease against the last known remote branch (i.e. after a rebase)")
+	noVerify := flag.Bool("no-verify", false, "Skip the pre-push hook when pushing")
+	var pushOptions stringList
+	flag.Var(&pushOptions, "push-option", "Push option to pass to the server (may be repeated)")
+	autoMerge := flag.String("auto-merge", "", "Enable auto-merge on the pull request with the given merge method (merge, squash or rebase)")
+	allowSecrets := flag.Bool("allow-secrets", false, "Create the issue even if the title or description appears to contain credentials")
+	discardDraft := flag.Bool("discard-draft", false, "Discard the description saved after a previous failed attempt")
+	fork := flag.Bool("fork", false, "Create the fork (User/BaseRepo) if it does not exist")
+	profile := flag.String("profile", "", "Settings profile to use (from [gitOpenPull \"profile.<name>\"] config)")
 
 	flag.Parse()
 
@@ -139,12 +220,12 @@ func main() {
 	var settings *Settings
 	var err error
 	if *interactive {
-		settings, err = LoadSettings(ctx)
+		settings, err = LoadSettings(ctx, *profile)
 		if err != nil {
 			log.Fatal(err)
 		}
 	} else {
-		settings, err = readSettingsConfig(ctx)
+		settings, err = readSettingsConfig(ctx, *profile)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -152,16 +233,34 @@ func main() {
 			for _, h := range hints {
 				fmt.Fprintln(os.Stderr, h)
 			}
+			RunErrorHooks(ctx, settings, nil, errors.New("required settings are missing"))
 			os.Exit(1)
 		}
 	}
+	settings.warnIgnoredHooks()
+
+	if *allowSecrets && settings.SecretScan != "off" {
+		settings.SecretScan = "warn"
+	}
+
+	// hc describes this run to hooks; on-error hooks are run before exiting
+	hc := &HookContext{}
+	fatal := func(err error) {
+		RunErrorHooks(ctx, settings, hc, err)
+		log.Fatal(err)
+	}
+	// exit is like fatal for errors that have already been reported
+	exit := func(err error) {
+		RunErrorHooks(ctx, settings, hc, err)
+		os.Exit(1)
+	}
 
 	client := SetupClient(ctx, settings)
 
 	if *listLabels {
 		labels, err := Labels(ctx, client, settings)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		for _, label := range labels {
 			fmt.Println(label)
@@ -169,75 +268,150 @@ func main() {
 		return
 	}
 
-	var labelSlice []string
+	labelSlice := settings.Labels
 	if *labels != "" {
-		labelSlice = strings.Split(*labels, ",")
-		for idx := range labelSlice {
-			labelSlice[idx] = strings.TrimSpace(labelSlice[idx])
-		}
+		labelSlice = splitList(*labels)
 	}
+	hc.Labels = labelSlice
 
 	var descriptionString string
 	if *description != "" {
 		fileContent, err := os.ReadFile(*description)
 		if err != nil {
-			log.Fatalf("error reading description file: %v", err)
+			fatal(fmt.Errorf("error reading description file: %v", err))
 		}
 		descriptionString = string(fileContent)
 	}
 
 	// Validate flag combinations
+	if _, ok := mergeMethods[*autoMerge]; *autoMerge != "" && !ok {
+		fatal(fmt.Errorf("invalid --auto-merge %q; expected merge, squash or rebase", *autoMerge))
+	}
+	if *autoMerge != "" {
+		// auto-merge requires a pull request that is ready for review
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "draft" && *draft {
+				fatal(errors.New("--auto-merge can not be used with --draft"))
+			}
+		})
+		*draft = false
+	}
 	if !*interactive && *description != "" && *title == "" {
-		log.Fatal("--title is required when using --description-file with --interactive=false")
+		fatal(errors.New("--title is required when using --description-file with --interactive=false"))
 	}
 
 	branch, err := GitFeatureBranch(ctx)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	fmt.Printf("current branch %s\n", branch)
+	if *discardDraft {
+		err = DiscardDraft(ctx, branch)
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	preflight, err := Preflight(ctx, settings, branch, !*dryRun)
+	if err != nil {
+		fatal(err)
+	}
+	if PrintPreflight(os.Stderr, preflight) && !*dryRun {
+		fatal(errors.New("preflight checks failed; see gitOpenPull.preflight to change which checks are errors"))
+	}
+
 	switch branch {
 	case "main", "master":
+		if *dryRun {
+			break
+		}
 		yn, err := input.Ask(fmt.Sprintf("Are you sure you want to make a pull request from %s? [y/N]", branch), "")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		if yn != "y" && yn != "Y" {
-			os.Exit(1)
+			exit(fmt.Errorf("not making a pull request from %s", branch))
 		}
 	}
 	detectedIssueNumber := DetectIssueNumber(branch)
 
+	// --fork pushes to a fork even when same repository mode would be detected
+	var sameRepo bool
+	switch {
+	case *fork && settings.SameRepo == "true":
+		fatal(errors.New("--fork can not be used with gitOpenPull.sameRepo=true"))
+	case !*fork:
+		sameRepo, err = DetectSameRepo(ctx, client, settings)
+		if err != nil {
+			fatal(err)
+		}
+	}
+	pushOwner := PushOwner(settings, sameRepo)
+
+	if *dryRun {
+		if detectedIssueNumber == 0 {
+			fmt.Printf("would create an issue and rename branch %s to %s_<issue>\n", branch, branch)
+		} else {
+			fmt.Printf("would use issue %s/%s#%d\n", settings.BaseAccount, settings.BaseRepo, detectedIssueNumber)
+		}
+		fmt.Printf("would push to %s and open a pull request into %s/%s branch %s\n", pushOwner, settings.BaseAccount, settings.BaseRepo, settings.BaseBranch)
+		for _, r := range preflight {
+			if r.Level == "error" {
+				exit(errors.New("preflight checks failed"))
+			}
+		}
+		return
+	}
+
+	// the git remote branches are pushed to; named after pushOwner unless an
+	// existing remote (i.e. origin) refers to the repository
+	var pushRemote string
+	if sameRepo {
+		pushRemote, err = UpstreamRemote(ctx, client, settings)
+	} else {
+		// make sure there is a fork to push to before creating an issue
+		pushRemote, err = EnsureFork(ctx, client, settings, *fork, *interactive)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
 	// create issue if needed
-	issueNumber, err := GetIssueNumber(ctx, client, settings, detectedIssueNumber, *interactive, *title, descriptionString, labelSlice)
+	issueNumber, err := GetIssueNumber(ctx, client, settings, hc, detectedIssueNumber, *interactive, *title, descriptionString, labelSlice)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	if issueNumber == 0 {
-		log.Fatal("expected issue number")
+		fatal(errors.New("expected issue number"))
 	}
+	hc.Issue = issueNumber
 
 	// Do we need/want to rename the branch?
+	// If the branch was already pushed under its current name, the remote copy
+	// is removed after the renamed branch is pushed.
+	var staleRemote, staleBranch string
+	preRenameBranch := branch
 	if issueNumber != detectedIssueNumber {
+		staleRemote, staleBranch = StaleBranch(ctx, settings, pushRemote, branch)
 		if *interactive {
 			yn, err := input.Ask(fmt.Sprintf("rename branch to %s_%d [Y/n]", branch, issueNumber), "")
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 			switch yn {
 			case "", "y", "Y":
 				branch, err = RenameBranch(ctx, branch, issueNumber)
 				if err != nil {
-					log.Fatal(err)
+					fatal(err)
 				}
 			case "n", "N":
 			default:
-				log.Fatalf("unknown response %q", yn)
+				fatal(fmt.Errorf("unknown response %q", yn))
 			}
 		} else {
 			branch, err = RenameBranch(ctx, branch, issueNumber)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
 
@@ -246,16 +420,26 @@ func main() {
 	// confirm issue number is valid and issue is open
 	issue, _, err := client.Issues.Get(ctx, settings.BaseAccount, settings.BaseRepo, issueNumber)
 	if err != nil {
-		log.Fatalf("error verifying issue %d %s", issueNumber, err)
+		fatal(fmt.Errorf("error verifying issue %d %s", issueNumber, err))
 	}
 	if *issue.State != "open" {
-		log.Fatalf("error: Issue %s/%s#%d is %s (%s)", settings.BaseAccount, settings.BaseRepo, issueNumber, *issue.State, *issue.Title)
+		fatal(fmt.Errorf("error: Issue %s/%s#%d is %s (%s)", settings.BaseAccount, settings.BaseRepo, issueNumber, *issue.State, *issue.Title))
 	}
 
-	fmt.Printf("pushing branch %s to %s\n", branch, settings.User)
-	_, err = RunGit(ctx, "push", "-u", settings.User, branch)
+	fmt.Printf("pushing branch %s to %s\n", branch, pushRemote)
+	err = PushWithHooks(ctx, settings, hc, pushRemote, branch, PushOptions{
+		ForceWithLease: *forceWithLease,
+		PushOptions:    pushOptions,
+		NoVerify:       *noVerify,
+	})
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+	if staleRemote != "" && branch != preRenameBranch {
+		err = RemoveStaleBranch(ctx, staleRemote, staleBranch, *interactive)
+		if err != nil {
+			log.Printf("error removing branch %s from %s %s", staleBranch, staleRemote, err)
+		}
 	}
 
 	// GitHub needs a variable amount of time before a new branch
@@ -263,18 +447,13 @@ func main() {
 	time.Sleep(2 * time.Second)
 
 	// check branch exists on remote
-	branches, _, err := client.Repositories.ListBranches(ctx, settings.User, settings.BaseRepo, &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}})
-	if err != nil {
-		log.Fatal(err)
-	}
-	var foundBranch bool
-	for _, b := range branches {
-		if *b.Name == branch {
-			foundBranch = true
+	_, _, err = client.Repositories.GetBranch(ctx, pushOwner, settings.BaseRepo, branch, 0)
+	if isNotFound(err) {
+		fmt.Printf("Error: branch %s does not exist in %s/%s\n", branch, pushOwner, settings.BaseRepo)
+		branches, _, err := client.Repositories.ListBranches(ctx, pushOwner, settings.BaseRepo, &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}})
+		if err != nil {
+			fatal(err)
 		}
-	}
-	if !foundBranch {
-		fmt.Printf("Error: branch %s does not exist in %s/%s\n", branch, settings.User, settings.BaseRepo)
 		if len(branches) > 1 {
 			fmt.Printf("valid branches are:")
 			for i, b := range branches {
@@ -284,26 +463,29 @@ func main() {
 				fmt.Printf("%s", *b.Name)
 			}
 		}
-		os.Exit(1)
+		exit(fmt.Errorf("branch %s does not exist in %s/%s", branch, pushOwner, settings.BaseRepo))
+	}
+	if err != nil {
+		fatal(err)
 	}
 
 	fmt.Printf("Issue: %d (%s)\n", issueNumber, *issue.Title)
-	head := fmt.Sprintf("%s:%s", settings.User, branch)
+	head := PullRequestHead(settings, sameRepo, branch)
 	fmt.Printf("pulling from %s into %s/%s branch %s\n", head, settings.BaseAccount, settings.BaseRepo, settings.BaseBranch)
 	if *interactive {
 		yn, err := input.Ask("confirm [y/n]", "")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		if strings.ToLower(yn) != "y" {
-			log.Fatal("exiting")
+			fatal(errors.New("exiting"))
 		}
 	}
 
-	if *interactive {
+	if *interactive && *autoMerge == "" {
 		yn, err := input.Ask("Open as draft [Y/n]", "")
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		switch yn {
 		case "", "Y", "y":
@@ -321,41 +503,34 @@ func main() {
 		MaintainerCanModify: &settings.MaintainersCanModify,
 		Draft:               draft,
 	}
-	_, _, err = client.PullRequests.Create(ctx, settings.BaseAccount, settings.BaseRepo, params)
+	pr, _, err := client.PullRequests.Create(ctx, settings.BaseAccount, settings.BaseRepo, params)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
-	fmt.Printf("%s\n", issue.GetHTMLURL())
-	// set asignee (if needed) ?
-
-	if settings.Callback != "" {
-		// fetch the json of the current issue
-		tempFile, err := os.CreateTemp("", fmt.Sprintf("issue-%d", issueNumber))
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer os.Remove(tempFile.Name())
-		req, err := client.NewRequest("GET", fmt.Sprintf("/repos/%s/%s/pulls/%d", settings.BaseAccount, settings.BaseRepo, issueNumber), nil)
+	if len(settings.Reviewers) > 0 {
+		err = RequestReviewers(ctx, client, settings, issueNumber)
 		if err != nil {
-			log.Fatal(err)
+			log.Printf("error requesting reviewers %s", err)
 		}
+	}
+
+	fmt.Printf("%s\n", issue.GetHTMLURL())
 
-		resp, err := client.Do(ctx, req, tempFile)
-		tempFile.Sync()
-		tempFile.Close()
+	if *autoMerge != "" {
+		err = EnableAutoMerge(ctx, client, settings, pr, *autoMerge)
 		if err != nil {
-			log.Fatal(err)
-		}
-		if resp.StatusCode != 200 {
-			log.Fatalf("got unexpected response code %d", resp.StatusCode)
+			log.Printf("error enabling auto-merge: %s", err)
+		} else {
+			fmt.Printf("enabled auto-merge (%s)\n", *autoMerge)
 		}
+	}
+	// set asignee (if needed) ?
 
-		cmd := exec.CommandContext(ctx, settings.Callback, tempFile.Name())
-		out, err := cmd.CombinedOutput()
+	if len(settings.Callback) > 0 {
+		err = RunCallback(ctx, client, settings, hc, issueNumber)
 		if err != nil {
-			log.Printf("error on callback: %s:\n   %s", settings.Callback, out)
-			log.Fatal(err)
+			fatal(err)
 		}
 	}
 
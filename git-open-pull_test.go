@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestStaleBranch(t *testing.T) {
+	type testCase struct {
+		name   string
+		setup  [][]string
+		branch string
+		remote string
+		stale  string
+	}
+	tests := []testCase{
+		{
+			name:   "tracking same name",
+			setup:  [][]string{{"push", "-q", "-u", "origin", "feature"}},
+			branch: "feature",
+			remote: "origin",
+			stale:  "feature",
+		},
+		{
+			name:   "pushed without upstream",
+			setup:  [][]string{{"push", "-q", "origin", "feature"}},
+			branch: "feature",
+			remote: "origin",
+			stale:  "feature",
+		},
+		{name: "not pushed", branch: "feature"},
+		{
+			name:   "tracking origin/main",
+			setup:  [][]string{{"branch", "-q", "--set-upstream-to", "origin/main", "feature"}},
+			branch: "feature",
+		},
+		{
+			name:   "tracking local branch",
+			setup:  [][]string{{"branch", "-q", "--set-upstream-to", "main", "feature"}},
+			branch: "feature",
+		},
+		{
+			name:   "tracking other remote",
+			setup:  [][]string{{"remote", "add", "acme", "."}, {"config", "branch.feature.remote", "acme"}, {"config", "branch.feature.merge", "refs/heads/feature"}},
+			branch: "feature",
+		},
+		{
+			name:   "base branch",
+			setup:  [][]string{{"branch", "-q", "--set-upstream-to", "origin/main", "main"}},
+			branch: "main",
+		},
+		{
+			name:   "remote default branch",
+			setup:  [][]string{{"push", "-q", "-u", "origin", "develop"}, {"--git-dir", "origin.git", "symbolic-ref", "HEAD", "refs/heads/develop"}},
+			branch: "develop",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixtureRepo(t)
+			ctx := context.Background()
+			for _, args := range append([][]string{
+				{"init", "-q", "--bare", "-b", "main", "origin.git"},
+				{"remote", "add", "origin", "origin.git"},
+				{"push", "-q", "origin", "main"},
+				{"branch", "feature"},
+				{"branch", "develop"},
+			}, tc.setup...) {
+				if _, err := RunGit(ctx, args...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			settings := &Settings{BaseBranch: "main"}
+			remote, branch := StaleBranch(ctx, settings, "origin", tc.branch)
+			if remote != tc.remote || branch != tc.stale {
+				t.Errorf("got %q %q expected %q %q", remote, branch, tc.remote, tc.stale)
+			}
+		})
+	}
+}
+
+func TestDryRunPreflightExit(t *testing.T) {
+	if os.Getenv("GITOPENPULL_TEST_MAIN") != "" {
+		// run as a subprocess below so the exit status can be checked
+		os.Args = []string{"git-open-pull", "--dry-run", "--interactive=false"}
+		main()
+		return
+	}
+	type testCase struct {
+		name   string
+		policy string
+		failed bool
+	}
+	tests := []testCase{
+		{"empty", "", true},
+		{"empty warn", "empty=warn", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixtureRepo(t)
+			if _, err := RunGit(context.Background(), "update-ref", "refs/remotes/acme/main", "HEAD"); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command(os.Args[0], "-test.run=^TestDryRunPreflightExit$")
+			cmd.Env = append(os.Environ(),
+				"GITOPENPULL_TEST_MAIN=1",
+				"GITOPENPULL_USER=jehiah",
+				"GITOPENPULL_TOKEN=token",
+				"GITOPENPULL_BASE_ACCOUNT=acme",
+				"GITOPENPULL_BASE_REPO=widgets",
+				"GITOPENPULL_SAME_REPO=false",
+				"GITOPENPULL_PREFLIGHT="+tc.policy,
+			)
+			out, err := cmd.CombinedOutput()
+			var exitErr *exec.ExitError
+			if failed := errors.As(err, &exitErr) && exitErr.ExitCode() == 1; failed != tc.failed {
+				t.Errorf("got %v expected failed=%v\n%s", err, tc.failed, out)
+			}
+			if !strings.Contains(string(out), "would push to jehiah") {
+				t.Errorf("expected the dry run plan\n%s", out)
+			}
+		})
+	}
+}
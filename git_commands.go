@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -17,13 +20,179 @@ func RunGit(ctx context.Context, arg ...string) ([]byte, error) {
 	return body, nil
 }
 
+// RunGitVerbose runs git with output going to stdout and stderr
+func RunGitVerbose(ctx context.Context, arg ...string) error {
+	cmd := exec.CommandContext(ctx, "git", arg...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s running \"git %s\"", err, strings.Join(arg, " "))
+	}
+	return nil
+}
+
 func GitFeatureBranch(ctx context.Context) (string, error) {
 	body, err := RunGit(ctx, "rev-parse", "--abbrev-ref", "HEAD")
 	return strings.TrimSpace(string(body)), err
 }
 
-func MergeBase(ctx context.Context, settings *Settings) (string, error) {
+// PushOptions controls how a branch is pushed
+type PushOptions struct {
+	// RemoteBranch is the branch to push to when it is named differently from
+	// the local branch (i.e. the head of a pull request that was checked out)
+	RemoteBranch string
+	// ForceWithLease allows overwriting the remote branch as long as it still
+	// points to the last known commit in refs/remotes/<remote>/<remote branch>
+	ForceWithLease bool
+	// PushOptions are passed to the server with --push-option
+	PushOptions []string
+	// NoVerify skips the pre-push hook
+	NoVerify bool
+}
+
+// Push pushes branch to remote and sets it as the upstream branch. On failure
+// the error includes the output from git verbatim.
+func Push(ctx context.Context, remote, branch string, opts PushOptions) error {
+	remoteBranch := branch
+	if opts.RemoteBranch != "" {
+		remoteBranch = opts.RemoteBranch
+	}
+	args := []string{"push", "-u"}
+	if opts.ForceWithLease {
+		// an empty expected value requires that the remote branch does not exist yet
+		expect, _ := RunGit(ctx, "rev-parse", "--verify", "--quiet", fmt.Sprintf("refs/remotes/%s/%s", remote, remoteBranch))
+		args = append(args, fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", remoteBranch, strings.TrimSpace(string(expect))))
+	}
+	for _, o := range opts.PushOptions {
+		args = append(args, "--push-option="+o)
+	}
+	if opts.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	args = append(args, remote, fmt.Sprintf("%s:refs/heads/%s", branch, remoteBranch))
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s running \"git %s\":\n%s", err, strings.Join(args, " "), strings.TrimRight(stderr.String(), "\n"))
+	}
+	return nil
+}
+
+// UpstreamBranch returns the remote (which is "." for a local branch) and ref
+// (i.e. refs/heads/main) that branch tracks, or empty strings when it has no
+// upstream
+func UpstreamBranch(ctx context.Context, branch string) (string, string) {
+	remote, err := RunGit(ctx, "config", fmt.Sprintf("branch.%s.remote", branch))
+	if err != nil {
+		return "", ""
+	}
+	merge, err := RunGit(ctx, "config", fmt.Sprintf("branch.%s.merge", branch))
+	if err != nil {
+		return "", ""
+	}
+	return strings.TrimSpace(string(remote)), strings.TrimSpace(string(merge))
+}
+
+// RemoteDefaultBranch returns the branch HEAD refers to on remote, or an empty
+// string if it can not be determined
+func RemoteDefaultBranch(ctx context.Context, remote string) string {
+	body, err := RunGit(ctx, "ls-remote", "--symref", remote, "HEAD")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		if ref, ok := strings.CutPrefix(line, "ref: "); ok {
+			ref, _, _ = strings.Cut(ref, "\t")
+			return strings.TrimPrefix(ref, "refs/heads/")
+		}
+	}
+	return ""
+}
+
+// RemoteBranchSHA returns the commit a branch on remote points to, or an empty
+// string if the branch does not exist
+func RemoteBranchSHA(ctx context.Context, remote, branch string) string {
+	body, err := RunGit(ctx, "ls-remote", "--heads", remote, "refs/heads/"+branch)
+	if err != nil {
+		return ""
+	}
+	sha, _, _ := strings.Cut(strings.TrimSpace(string(body)), "\t")
+	return sha
+}
+
+// IsAncestor reports if commit is reachable from HEAD
+func IsAncestor(ctx context.Context, commit string) bool {
+	_, err := RunGit(ctx, "merge-base", "--is-ancestor", commit, "HEAD")
+	return err == nil
+}
+
+// GitStatus returns the paths of files with uncommitted changes to tracked
+// files, and the paths of untracked (and not ignored) files
+func GitStatus(ctx context.Context) (changed []string, untracked []string, err error) {
+	body, err := RunGit(ctx, "status", "--porcelain")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		if strings.HasPrefix(line, "??") {
+			untracked = append(untracked, line[3:])
+			continue
+		}
+		changed = append(changed, line[3:])
+	}
+	return changed, untracked, nil
+}
+
+// CommitsBehind returns the number of commits in ref that are not in HEAD
+func CommitsBehind(ctx context.Context, ref string) (int, error) {
+	body, err := RunGit(ctx, "rev-list", "--count", fmt.Sprintf("HEAD..%s", ref))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(body)))
+}
+
+// LocalBranches returns the names of all local branches
+func LocalBranches(ctx context.Context) ([]string, error) {
+	body, err := RunGit(ctx, "for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(body)), nil
+}
+
+// GitTopLevel returns the root directory of the current repository
+func GitTopLevel(ctx context.Context) (string, error) {
+	body, err := RunGit(ctx, "rev-parse", "--show-toplevel")
+	return strings.TrimSpace(string(body)), err
+}
+
+// FetchBase fetches settings.BaseBranch from the settings.BaseAccount remote into FETCH_HEAD
+func FetchBase(ctx context.Context, settings *Settings) error {
 	_, err := RunGit(ctx, "fetch", settings.BaseAccount, fmt.Sprintf("+refs/heads/%s", settings.BaseBranch))
+	return err
+}
+
+// BaseRef returns the remote tracking ref for settings.BaseBranch as last
+// fetched from the settings.BaseAccount remote
+func BaseRef(settings *Settings) string {
+	return fmt.Sprintf("refs/remotes/%s/%s", settings.BaseAccount, settings.BaseBranch)
+}
+
+// LocalMergeBase returns the merge base of HEAD and BaseRef. Unlike MergeBase
+// it does not fetch, so it may be out of date.
+func LocalMergeBase(ctx context.Context, settings *Settings) (string, error) {
+	base, err := RunGit(ctx, "merge-base", BaseRef(settings), "HEAD")
+	return strings.TrimSpace(string(base)), err
+}
+
+func MergeBase(ctx context.Context, settings *Settings) (string, error) {
+	err := FetchBase(ctx, settings)
 	if err != nil {
 		return "", err
 	}
@@ -43,9 +212,12 @@ func reverse(ss []string) {
 // the oldest commit (first) is returned first
 func Commits(ctx context.Context, base string) ([]string, error) {
 	output, err := RunGit(ctx, "log", "--format=%H", fmt.Sprintf("%s...HEAD", base))
+	if err != nil || len(bytes.TrimSpace(output)) == 0 {
+		return nil, err
+	}
 	commits := strings.Split(strings.TrimSpace(string(output)), "\n")
 	reverse(commits)
-	return commits, err
+	return commits, nil
 }
 
 // CommitDetails gets the subject and body for a commit
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPushRemoteBranch(t *testing.T) {
+	fixtureRepo(t)
+	ctx := context.Background()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := RunGit(ctx, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "--bare", "-b", "main", "origin.git")
+	git("remote", "add", "origin", "origin.git")
+	git("checkout", "-q", "-b", "feature_11_12")
+	git("commit", "-q", "--allow-empty", "-m", "one")
+
+	// the remote branch does not exist yet
+	opts := PushOptions{RemoteBranch: "feature_11", ForceWithLease: true}
+	if err := Push(ctx, "origin", "feature_11_12", opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := RemoteBranchSHA(ctx, "origin", "feature_11"), git("rev-parse", "HEAD"); got != expected {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+	if sha := RemoteBranchSHA(ctx, "origin", "feature_11_12"); sha != "" {
+		t.Errorf("unexpected branch feature_11_12 at %s", sha)
+	}
+
+	// rewriting the branch is allowed while the remote matches refs/remotes/origin/feature_11
+	git("commit", "-q", "--amend", "--allow-empty", "-m", "one amended")
+	if err := Push(ctx, "origin", "feature_11_12", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// but not once someone else has pushed to it
+	git("push", "-q", "origin.git", "HEAD~1:refs/heads/feature_11", "--force")
+	git("commit", "-q", "--amend", "--allow-empty", "-m", "one amended again")
+	err := Push(ctx, "origin", "feature_11_12", opts)
+	if err == nil || !strings.Contains(err.Error(), "stale info") {
+		t.Errorf("got %v expected the lease to fail", err)
+	}
+}
+
+func TestPushOptions(t *testing.T) {
+	fixtureRepo(t)
+	ctx := context.Background()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := RunGit(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q", "--bare", "-b", "main", "origin.git")
+	git("-C", "origin.git", "config", "receive.advertisePushOptions", "true")
+	git("remote", "add", "origin", "origin.git")
+	git("checkout", "-q", "-b", "feature_12")
+	// the server records push options; the local pre-push hook rejects every push
+	hooks := map[string]string{
+		"origin.git/hooks/pre-receive": "#!/bin/sh\necho \"$GIT_PUSH_OPTION_COUNT $GIT_PUSH_OPTION_0\" > ../push-options\n",
+		".git/hooks/pre-push":          "#!/bin/sh\necho rejected by pre-push >&2\nexit 1\n",
+	}
+	for name, script := range hooks {
+		if err := os.WriteFile(name, []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Push(ctx, "origin", "feature_12", PushOptions{})
+	if err == nil || !strings.Contains(err.Error(), "rejected by pre-push") {
+		t.Errorf("got %v expected the pre-push hook's output", err)
+	}
+
+	if err := Push(ctx, "origin", "feature_12", PushOptions{NoVerify: true, PushOptions: []string{"ci.skip"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile("push-options"); string(got) != "1 ci.skip\n" {
+		t.Errorf("got push options %q expected %q", got, "1 ci.skip\n")
+	}
+
+	// a lease rejection includes git's explanation
+	git("commit", "-q", "--allow-empty", "-m", "pushed by someone else")
+	git("push", "-q", "--no-verify", "origin.git", "HEAD:refs/heads/feature_12")
+	git("reset", "-q", "--hard", "HEAD~1")
+	git("commit", "-q", "--allow-empty", "-m", "rewritten")
+	err = Push(ctx, "origin", "feature_12", PushOptions{ForceWithLease: true, NoVerify: true})
+	if err == nil || !strings.Contains(err.Error(), "stale info") {
+		t.Errorf("got %v expected the lease to fail with git's stderr", err)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/jehiah/git-open-pull
 go 1.23.0
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/google/go-github/v60 v60.0.0
 	github.com/jehiah/agentdetection v0.0.0-20260504180809-d55902bec14c
 	golang.org/x/oauth2 v0.30.0
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// GraphQLError is returned when a GraphQL response includes errors
+type GraphQLError []graphQLError
+
+func (e GraphQLError) Error() string {
+	var o []string
+	for _, err := range e {
+		o = append(o, err.Message)
+	}
+	return strings.Join(o, "; ")
+}
+
+// GraphQL runs a query (or mutation) against the GitHub GraphQL API and
+// decodes the `data` field of the response into result
+func GraphQL(ctx context.Context, client *github.Client, query string, variables map[string]interface{}, result interface{}) error {
+	// GitHub Enterprise serves the REST API at /api/v3/ and GraphQL at /api/graphql
+	endpoint := "graphql"
+	if strings.HasSuffix(client.BaseURL.Path, "/v3/") {
+		endpoint = "../graphql"
+	}
+	req, err := client.NewRequest("POST", endpoint, &graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data   interface{}  `json:"data"`
+		Errors GraphQLError `json:"errors"`
+	}
+	resp.Data = result
+	if _, err = client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestGraphQL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" && r.URL.Path != "/api/graphql" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		switch req.Variables["id"] {
+		case "ok":
+			w.Write([]byte(`{"data": {"node": {"number": 42}}}`))
+		default:
+			w.Write([]byte(`{"data": null, "errors": [{"type": "UNPROCESSABLE", "message": "Pull request Auto merge is not allowed for this repository"}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	for _, base := range []string{"/", "/api/v3/"} {
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(ts.URL + base)
+
+		var result struct {
+			Node struct {
+				Number int `json:"number"`
+			} `json:"node"`
+		}
+		err := GraphQL(context.Background(), client, "query", map[string]interface{}{"id": "ok"}, &result)
+		if err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+		if result.Node.Number != 42 {
+			t.Errorf("got %d expected 42", result.Node.Number)
+		}
+
+		err = GraphQL(context.Background(), client, "query", map[string]interface{}{"id": "fail"}, nil)
+		if _, ok := err.(GraphQLError); !ok {
+			t.Errorf("got %#v expected GraphQLError", err)
+		}
+	}
+}
+
+func TestEnableAutoMerge(t *testing.T) {
+	type testCase struct {
+		name    string
+		repo    string
+		draft   bool
+		err     string
+		mutated bool
+	}
+	tests := []testCase{
+		{name: "allowed", repo: `{"allow_auto_merge": true}`, mutated: true},
+		{name: "unknown", repo: `{}`, mutated: true},
+		{name: "not allowed", repo: `{"allow_auto_merge": false}`, err: "enable \"Allow auto-merge\""},
+		{name: "draft", repo: `{"allow_auto_merge": true}`, draft: true, err: "draft pull request"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var mutated bool
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/acme/widgets":
+					w.Write([]byte(tc.repo))
+				case "/graphql":
+					mutated = true
+					w.Write([]byte(`{"data": {}}`))
+				default:
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+			}))
+			defer ts.Close()
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(ts.URL + "/")
+			settings := &Settings{BaseAccount: "acme", BaseRepo: "widgets"}
+			pr := &github.PullRequest{Number: github.Int(12), NodeID: github.String("PR_1"), Draft: github.Bool(tc.draft)}
+			err := EnableAutoMerge(context.Background(), client, settings, pr, "squash")
+			switch {
+			case tc.err == "" && err != nil:
+				t.Errorf("unexpected error %s", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Errorf("got error %v expected %q", err, tc.err)
+			}
+			if mutated != tc.mutated {
+				t.Errorf("got mutation %v expected %v", mutated, tc.mutated)
+			}
+		})
+	}
+}
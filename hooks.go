@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// HookContext describes what git-open-pull is doing when a hook runs. It is
+// passed to hooks as GITOPENPULL_* environment variables and, when
+// gitOpenPull.hookContext is enabled, as a JSON file named by
+// GITOPENPULL_HOOK_CONTEXT_FILE.
+type HookContext struct {
+	Stage string `json:"stage"`
+	// every setting except secrets, keyed by git config key
+	Settings    map[string]string `json:"settings"`
+	Branch      string            `json:"branch"`
+	MergeBase   string            `json:"merge_base"`
+	Commits     []Commit          `json:"commits"`
+	Labels      []string          `json:"labels"`
+	Issue       int               `json:"issue,omitempty"`
+	PullRequest int               `json:"pull_request,omitempty"`
+	// the error passed to on-error hooks
+	Error string `json:"error,omitempty"`
+
+	loaded bool
+}
+
+// load fills in the settings, branch, merge base and commits the first time
+// a hook runs. The merge base is found without fetching (see LocalMergeBase).
+func (hc *HookContext) load(ctx context.Context, settings *Settings) {
+	if hc.loaded {
+		return
+	}
+	hc.loaded = true
+	hc.Settings = make(map[string]string)
+	for _, k := range settingKeys {
+		if !k.Secret {
+			hc.Settings[k.Key] = k.value(settings)
+		}
+	}
+	hc.Branch, _ = GitFeatureBranch(ctx)
+	mergeBase, err := LocalMergeBase(ctx, settings)
+	if err != nil {
+		log.Printf("error getting merge base %s", err)
+		return
+	}
+	hc.MergeBase = mergeBase
+	hc.Commits, err = LoadCommits(ctx, mergeBase)
+	if err != nil {
+		log.Printf("error getting commits %s", err)
+	}
+}
+
+// environ returns the GITOPENPULL_* environment variables for a hook
+func (hc *HookContext) environ(settings *Settings) []string {
+	env := []string{
+		"GITOPENPULL_HOOK=" + hc.Stage,
+		"GITOPENPULL_HOOK_USER=" + settings.User,
+		"GITOPENPULL_HOOK_BASE_ACCOUNT=" + settings.BaseAccount,
+		"GITOPENPULL_HOOK_BASE_REPO=" + settings.BaseRepo,
+		"GITOPENPULL_HOOK_BASE_BRANCH=" + settings.BaseBranch,
+		"GITOPENPULL_HOOK_BRANCH=" + hc.Branch,
+		"GITOPENPULL_HOOK_MERGE_BASE=" + hc.MergeBase,
+		"GITOPENPULL_HOOK_LABELS=" + strings.Join(hc.Labels, ","),
+	}
+	if hc.Issue != 0 {
+		env = append(env, "GITOPENPULL_HOOK_ISSUE="+strconv.Itoa(hc.Issue))
+	}
+	if hc.PullRequest != 0 {
+		env = append(env, "GITOPENPULL_HOOK_PULL_REQUEST="+strconv.Itoa(hc.PullRequest))
+	}
+	if hc.Error != "" {
+		env = append(env, "GITOPENPULL_HOOK_ERROR="+hc.Error)
+	}
+	return env
+}
+
+// writeContextFile writes hc as JSON to a temporary file
+func (hc *HookContext) writeContextFile() (string, error) {
+	f, err := os.CreateTemp("", "git-open-pull-context")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	if err := e.Encode(hc); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// hookTimeout parses gitOpenPull.hookTimeout; zero means hooks are not timed out
+func (s Settings) hookTimeout() (time.Duration, error) {
+	if s.HookTimeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s.HookTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gitOpenPull.hookTimeout %q: %w", s.HookTimeout, err)
+	}
+	return d, nil
+}
+
+// hookCommand builds the command for a hook the way git runs an alias. A
+// command starting with "!" is run by the shell with args available as "$@";
+// otherwise it is split into words (respecting quotes) and args are appended.
+// Hooks run from the repository root and a relative path like
+// scripts/hook.sh is resolved from it.
+func hookCommand(ctx context.Context, root, command string, args ...string) (*exec.Cmd, error) {
+	var cmd *exec.Cmd
+	if shell, ok := strings.CutPrefix(command, "!"); ok {
+		cmd = exec.CommandContext(ctx, "sh", append([]string{"-c", shell + ` "$@"`, shell}, args...)...)
+	} else {
+		words, err := splitCommand(command)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hook %q: %w", command, err)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty hook command")
+		}
+		if root != "" && strings.Contains(words[0], "/") && !filepath.IsAbs(words[0]) {
+			words[0] = filepath.Join(root, words[0])
+		}
+		cmd = exec.CommandContext(ctx, words[0], append(words[1:], args...)...)
+	}
+	cmd.Dir = root
+	return cmd, nil
+}
+
+// splitCommand splits a command into words like a POSIX shell: words are
+// separated by whitespace, which single quotes, double quotes and backslashes
+// escape. Inside double quotes a backslash only escapes $, `, ", \ and a
+// newline, and a backslash before a newline is removed.
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var inWord, escaped bool
+	var quote rune
+	for _, r := range command {
+		switch {
+		case escaped:
+			escaped = false
+			if r == '\n' {
+				continue
+			}
+			if quote == '"' && !strings.ContainsRune("$`\"\\", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			inWord = true
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// RunHook runs command for a hook stage (i.e. "pre-process") with args and
+// the environment described by hc. The hook's stderr is streamed; its stdout
+// is shown if it fails.
+func RunHook(ctx context.Context, settings *Settings, hc *HookContext, stage, command string, args ...string) error {
+	if hc == nil {
+		hc = &HookContext{}
+	}
+	hc.load(ctx, settings)
+	hc.Stage = stage
+
+	timeout, err := settings.hookTimeout()
+	if err != nil {
+		return err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	root, _ := GitTopLevel(ctx)
+	cmd, err := hookCommand(ctx, root, command, args...)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(os.Environ(), hc.environ(settings)...)
+	if settings.HookContextFile {
+		filename, err := hc.writeContextFile()
+		if err != nil {
+			return err
+		}
+		defer os.Remove(filename)
+		cmd.Env = append(cmd.Env, "GITOPENPULL_HOOK_CONTEXT_FILE="+filename)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		log.Printf("error running %s hook: %s\n  error: %v\n  output: %s", stage, command, err, stdout.Bytes())
+		return err
+	}
+	return nil
+}
+
+// RunHooks runs each command configured for a hook stage in order, stopping
+// at the first that fails
+func RunHooks(ctx context.Context, settings *Settings, hc *HookContext, stage string, commands []string, args ...string) error {
+	for _, command := range commands {
+		if err := RunHook(ctx, settings, hc, stage, command, args...); err != nil {
+			return fmt.Errorf("%s hook %s failed: %w", stage, command, err)
+		}
+	}
+	return nil
+}
+
+// RunErrorHooks runs the on-error hooks with the error that stopped git-open-pull.
+// Failures are logged, not returned, as git-open-pull is already exiting.
+func RunErrorHooks(ctx context.Context, settings *Settings, hc *HookContext, cause error) {
+	if settings == nil || len(settings.OnError) == 0 {
+		return
+	}
+	if hc == nil {
+		hc = &HookContext{}
+	}
+	hc.Error = cause.Error()
+	for _, command := range settings.OnError {
+		if err := RunHook(ctx, settings, hc, "on-error", command); err != nil {
+			log.Printf("on-error hook %s failed: %s", command, err)
+		}
+	}
+}
+
+// PushWithHooks runs the pre-push hooks, pushes branch to remote and then runs
+// the post-push hooks. Hooks are run with the remote and branch as arguments.
+func PushWithHooks(ctx context.Context, settings *Settings, hc *HookContext, remote, branch string, opts PushOptions) error {
+	err := RunHooks(ctx, settings, hc, "pre-push", settings.PrePush, remote, branch)
+	if err != nil {
+		return err
+	}
+	err = Push(ctx, remote, branch, opts)
+	if err != nil {
+		return err
+	}
+	return RunHooks(ctx, settings, hc, "post-push", settings.PostPush, remote, branch)
+}
+
+// PreIssueHooks runs the pre-issue hooks with the first argument containing
+// the filename of a file with the JSON of the issue to be created
+func PreIssueHooks(ctx context.Context, settings *Settings, hc *HookContext, ir *github.IssueRequest) error {
+	if len(settings.PreIssue) == 0 {
+		return nil
+	}
+	filename, err := writeTempJSON(ir)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(filename)
+	return RunHooks(ctx, settings, hc, "pre-issue", settings.PreIssue, filename)
+}
+
+// writeTempJSON writes v as JSON to a temporary file
+func writeTempJSON(v interface{}) (string, error) {
+	tempFile, err := os.CreateTemp("", "git-open-pull")
+	if err != nil {
+		return "", err
+	}
+	defer tempFile.Close()
+	err = json.NewEncoder(tempFile).Encode(v)
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+	return tempFile.Name(), nil
+}
+
+// RunCallback runs the settings.Callback hooks with the first argument
+// containing the filename of a file with the JSON of pull request number
+func RunCallback(ctx context.Context, client *github.Client, settings *Settings, hc *HookContext, number int) error {
+	// fetch the json of the current issue
+	tempFile, err := os.CreateTemp("", fmt.Sprintf("issue-%d", number))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	req, err := client.NewRequest("GET", fmt.Sprintf("/repos/%s/%s/pulls/%d", settings.BaseAccount, settings.BaseRepo, number), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(ctx, req, tempFile)
+	tempFile.Sync()
+	tempFile.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("got unexpected response code %d", resp.StatusCode)
+	}
+
+	if hc == nil {
+		hc = &HookContext{}
+	}
+	hc.Issue, hc.PullRequest = number, number
+	var pr github.PullRequest
+	if body, err := os.ReadFile(tempFile.Name()); err == nil && json.Unmarshal(body, &pr) == nil {
+		hc.Labels = nil
+		for _, l := range pr.Labels {
+			hc.Labels = append(hc.Labels, l.GetName())
+		}
+	}
+	return RunHooks(ctx, settings, hc, "callback", settings.Callback, tempFile.Name())
+}
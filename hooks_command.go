@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// hookStages are the hook stages and the settings their commands are read from
+var hookStages = []struct {
+	Stage string
+	Key   string
+}{
+	{"pre-process", "gitOpenPull.preProcess"},
+	{"post-process", "gitOpenPull.postProcess"},
+	{"pre-issue", "gitOpenPull.preIssue"},
+	{"pre-push", "gitOpenPull.prePush"},
+	{"post-push", "gitOpenPull.postPush"},
+	{"callback", "gitOpenPull.callback"},
+	{"on-error", "gitOpenPull.onError"},
+}
+
+// hookCommands returns the commands configured for a stage, which can be
+// given by name (i.e. pre-push) or setting (i.e. prePush)
+func hookCommands(settings *Settings, stage string) (string, []string, bool) {
+	for _, h := range hookStages {
+		k, _ := lookupSettingKey(h.Key)
+		if h.Stage == stage || strings.EqualFold(k.name(), stage) {
+			var commands []string
+			if v := k.value(settings); v != "" {
+				commands = strings.Split(v, "\n")
+			}
+			return h.Stage, commands, true
+		}
+	}
+	return "", nil, false
+}
+
+// runHooksCommand implements `git-open-pull hooks [list|test <stage>]`
+func runHooksCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("hooks", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	fs.Parse(args)
+
+	settings, err := readSettingsConfig(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	settings.warnIgnoredHooks()
+	switch fs.Arg(0) {
+	case "", "list":
+		for _, h := range hookStages {
+			_, commands, _ := hookCommands(settings, h.Stage)
+			for _, c := range commands {
+				fmt.Printf("%-12s %s\n", h.Stage, c)
+			}
+		}
+		return nil
+	case "test":
+		if fs.NArg() != 2 {
+			return fmt.Errorf("usage: git-open-pull hooks test <stage>")
+		}
+		return testHooks(ctx, settings, fs.Arg(1))
+	default:
+		return fmt.Errorf("unknown hooks command %q", fs.Arg(0))
+	}
+}
+
+// testHooks runs the hooks for a stage with sample arguments
+func testHooks(ctx context.Context, settings *Settings, stage string) error {
+	stage, commands, ok := hookCommands(settings, stage)
+	if !ok {
+		var stages []string
+		for _, h := range hookStages {
+			stages = append(stages, h.Stage)
+		}
+		return fmt.Errorf("unknown hook stage; expected one of %s", strings.Join(stages, ", "))
+	}
+	if len(commands) == 0 {
+		return fmt.Errorf("no %s hooks are configured", stage)
+	}
+
+	hc := &HookContext{Labels: settings.Labels, Issue: 1, PullRequest: 1}
+	title, body := "Sample title", "Sample description."
+	var args []string
+	// sample is the template passed to pre and post process hooks
+	var sample string
+	switch stage {
+	case "pre-process", "post-process":
+		tempFile, err := os.CreateTemp("", "git-open-pull")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tempFile, "%s\n\n%s\n", title, body)
+		writeTemplateFooter(tempFile, "", settings.Labels, nil)
+		tempFile.Close()
+		sample = tempFile.Name()
+		args = []string{sample}
+		hc.Issue, hc.PullRequest = 0, 0
+	case "pre-issue":
+		filename, err := writeTempJSON(&github.IssueRequest{Title: &title, Body: &body, Labels: &hc.Labels, Assignee: &settings.User})
+		if err != nil {
+			return err
+		}
+		defer os.Remove(filename)
+		args = []string{filename}
+		hc.PullRequest = 0
+	case "pre-push", "post-push":
+		branch, err := GitFeatureBranch(ctx)
+		if err != nil {
+			return err
+		}
+		owner := PushOwner(settings, settings.SameRepo == "true")
+		remote := FindRemote(ctx, fmt.Sprintf("https://github.com/%s/%s", owner, settings.BaseRepo))
+		if remote == "" {
+			remote = owner
+		}
+		args = []string{remote, branch}
+	case "callback":
+		url := fmt.Sprintf("https://github.com/%s/%s/pull/1", settings.BaseAccount, settings.BaseRepo)
+		filename, err := writeTempJSON(&github.PullRequest{Number: github.Int(1), Title: &title, Body: &body, HTMLURL: &url, State: github.String("open")})
+		if err != nil {
+			return err
+		}
+		defer os.Remove(filename)
+		args = []string{filename}
+	case "on-error":
+		hc.Error = "sample error"
+	}
+	if sample != "" {
+		defer os.Remove(sample)
+	}
+
+	fmt.Printf("running %s hooks with arguments %q\n", stage, args)
+	err := RunHooks(ctx, settings, hc, stage, commands, args...)
+	if err != nil {
+		return err
+	}
+	if sample != "" {
+		// show how pre and post process hooks changed the template
+		content, err := os.ReadFile(sample)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", content)
+	}
+	fmt.Printf("%s hooks succeeded\n", stage)
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunHook(t *testing.T) {
+	base := fixtureRepo(t, "first")
+	ctx := context.Background()
+	// the merge base is found from the remote tracking branch without fetching
+	if _, err := RunGit(ctx, "update-ref", "refs/remotes/base/main", base); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	hook := filepath.Join(dir, "hook.sh")
+	script := "#!/bin/sh\necho \"$GITOPENPULL_HOOK $GITOPENPULL_HOOK_BRANCH $GITOPENPULL_HOOK_ISSUE $GITOPENPULL_HOOK_LABELS\" > \"$1\"\ncat \"$GITOPENPULL_HOOK_CONTEXT_FILE\" > \"$1.json\"\n"
+	if err := os.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := &Settings{User: "user", Token: "secret-token", BaseAccount: "base", BaseRepo: "repo", BaseBranch: "main", HookContextFile: true}
+	hc := &HookContext{Labels: []string{"a", "b"}, Issue: 12}
+	out := filepath.Join(dir, "out")
+	if err := RunHook(ctx, settings, hc, "pre-process", hook, out); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "pre-process main 12 a,b\n"; string(got) != expected {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+
+	body, err := os.ReadFile(out + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), settings.Token) {
+		t.Errorf("context file contains the token: %s", body)
+	}
+	var c HookContext
+	if err := json.Unmarshal(body, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Stage != "pre-process" || c.Branch != "main" || c.Issue != 12 || c.Settings["gitOpenPull.baseAccount"] != "base" {
+		t.Errorf("unexpected context %#v", c)
+	}
+	if c.MergeBase != base || len(c.Commits) != 1 || c.Commits[0].Subject != "first" {
+		t.Errorf("got merge base %q commits %#v expected %q", c.MergeBase, c.Commits, base)
+	}
+}
+
+func TestRunHookTimeout(t *testing.T) {
+	fixtureRepo(t)
+	settings := &Settings{HookTimeout: "100ms"}
+	err := RunHook(context.Background(), settings, nil, "callback", "sleep", "5")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("got %v expected a timeout", err)
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	fixtureRepo(t)
+	out := filepath.Join(t.TempDir(), "out")
+	commands := []string{"echo", "false", "echo"}
+	hook := filepath.Join(t.TempDir(), "hook.sh")
+	script := "#!/bin/sh\necho \"$GITOPENPULL_HOOK $1\" >> " + out + "\n"
+	if err := os.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	commands[0], commands[2] = hook, hook
+	err := RunHooks(context.Background(), &Settings{}, nil, "pre-push", commands, "origin")
+	if err == nil || !strings.Contains(err.Error(), "pre-push hook false failed") {
+		t.Errorf("got %v expected the second hook to fail", err)
+	}
+	got, _ := os.ReadFile(out)
+	if expected := "pre-push origin\n"; string(got) != expected {
+		t.Errorf("got %q expected %q; hooks after a failure should not run", got, expected)
+	}
+}
+
+func TestRunErrorHooks(t *testing.T) {
+	fixtureRepo(t)
+	var b bytes.Buffer
+	log.SetOutput(&b)
+	defer log.SetOutput(os.Stderr)
+	settings := &Settings{OnError: []string{"true"}, HookTimeout: "soon"}
+	RunErrorHooks(context.Background(), settings, nil, errors.New("push failed"))
+	if expected := `on-error hook true failed: invalid gitOpenPull.hookTimeout "soon"`; !strings.Contains(b.String(), expected) {
+		t.Errorf("got %q expected %q", b.String(), expected)
+	}
+}
+
+func TestHookSettings(t *testing.T) {
+	fixtureRepo(t)
+	t.Setenv("GITOPENPULL_CALLBACK", "")
+	t.Setenv("GITOPENPULL_TRUST_PROJECT_HOOKS", "")
+	ctx := context.Background()
+	if err := os.WriteFile(projectConfigFile, []byte("callback = [\"a\", \"b\"]\nprePush = \"c\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	check := func(prePush string, expected ...string) {
+		t.Helper()
+		s, err := readSettingsConfig(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(s.Callback, ","); got != strings.Join(expected, ",") {
+			t.Errorf("got callback %q expected %q", got, expected)
+		}
+		if got := strings.Join(s.PrePush, ","); got != prePush {
+			t.Errorf("got prePush %q expected %q", got, prePush)
+		}
+		if prePush == "" && !reflect.DeepEqual(s.ignoredHooks, []string{"callback (line 1)", "prePush (line 2)"}) {
+			t.Errorf("got ignored hooks %q", s.ignoredHooks)
+		}
+	}
+	// project hooks are ignored until the repository is trusted
+	check("")
+	RunGit(ctx, "config", "gitOpenPull.trustProjectHooks", "true")
+	check("c", "a", "b")
+
+	// git config values replace the project config
+	RunGit(ctx, "config", "--add", "gitOpenPull.callback", "d")
+	RunGit(ctx, "config", "--add", "gitOpenPull.callback", "e")
+	check("c", "d", "e")
+
+	// an empty value clears the values before it
+	RunGit(ctx, "config", "--add", "gitOpenPull.callback", "")
+	RunGit(ctx, "config", "--add", "gitOpenPull.callback", "f")
+	check("c", "f")
+
+	t.Setenv("GITOPENPULL_CALLBACK", "g")
+	check("c", "g")
+}
+
+func TestSplitCommand(t *testing.T) {
+	type testCase struct {
+		command  string
+		expected []string
+		err      bool
+	}
+	tests := []testCase{
+		{command: "my-script", expected: []string{"my-script"}},
+		{command: "  my-script --flag   value ", expected: []string{"my-script", "--flag", "value"}},
+		{command: `scripts/hook.sh --title "a b" 'c "d"'`, expected: []string{"scripts/hook.sh", "--title", "a b", `c "d"`}},
+		{command: `a\ b "c\"d" ''`, expected: []string{"a b", `c"d`, ""}},
+		{command: `my-script "C:\dir" "\$HOME \\ \a"`, expected: []string{"my-script", `C:\dir`, `$HOME \ \a`}},
+		{command: "my-script \\\n  --flag \"a\\\nb\"", expected: []string{"my-script", "--flag", "ab"}},
+		{command: `my-script "unterminated`, err: true},
+		{command: `my-script \`, err: true},
+	}
+	for _, tc := range tests {
+		got, err := splitCommand(tc.command)
+		if (err != nil) != tc.err {
+			t.Errorf("%q got error %v", tc.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%q got %q expected %q", tc.command, got, tc.expected)
+		}
+	}
+}
+
+func TestHookCommand(t *testing.T) {
+	fixtureRepo(t)
+	ctx := context.Background()
+	root, err := GitTopLevel(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "scripts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "scripts", "hook.sh"), []byte("#!/bin/sh\necho \"$@\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// hooks are resolved from the repository root, not the working directory
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(root, "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		command  string
+		expected string
+	}
+	tests := []testCase{
+		{"scripts/hook.sh --flag 'a b'", "--flag a b file\n"},
+		{"!echo $(basename $(pwd)) first", filepath.Base(root) + " first file\n"},
+		{"!f() { echo \"got $1\"; }; f", "got file\n"},
+	}
+	for _, tc := range tests {
+		cmd, err := hookCommand(ctx, root, tc.command, "file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%q %s", tc.command, err)
+		}
+		if string(out) != tc.expected {
+			t.Errorf("%q got %q expected %q", tc.command, out, tc.expected)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -11,8 +12,10 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v60/github"
+	"github.com/jehiah/git-open-pull/internal/input"
 )
 
 // DetectIssueNumber parses out an existing issue from passed in branch name.
@@ -37,17 +40,25 @@ func DetectIssueNumber(branch string) int {
 	return issueNumber
 }
 
-func NewIssue(ctx context.Context, client *github.Client, settings *Settings, interactive bool, title, description string, labels []string) (issueNumber int, err error) {
+func NewIssue(ctx context.Context, client *github.Client, settings *Settings, hc *HookContext, interactive bool, title, description string, labels []string) (issueNumber int, err error) {
 	var gir *github.IssueRequest
 	if interactive {
-		gir, err = PopulateIssueInteractive(ctx, client, settings, title, description, labels)
+		gir, err = PopulateIssueInteractive(ctx, client, settings, hc, title, description, labels)
 		if err != nil {
-			log.Fatalf("Interactive issue creation failed: %v", err)
+			return 0, fmt.Errorf("interactive issue creation failed: %w", err)
 		}
 
 	} else {
 		if title == "" {
-			log.Fatal("title cannot be empty")
+			return 0, fmt.Errorf("title cannot be empty")
+		}
+
+		results, err := Lint(settings, title, description)
+		if err != nil {
+			return 0, err
+		}
+		if PrintLint(os.Stderr, results) {
+			return 0, errLint
 		}
 
 		gir = &github.IssueRequest{
@@ -61,10 +72,30 @@ func NewIssue(ctx context.Context, client *github.Client, settings *Settings, in
 		}
 	}
 
-	i, _, err := client.Issues.Create(ctx, settings.BaseAccount, settings.BaseRepo, gir)
+	if !interactive {
+		// the interactive flow already checked for secrets while editing
+		err = CheckSecrets(os.Stderr, settings, gir.GetTitle(), gir.GetBody())
+	}
+	if err == nil {
+		err = PreIssueHooks(ctx, settings, hc, gir)
+	}
+	var i *github.Issue
+	if err == nil {
+		i, _, err = client.Issues.Create(ctx, settings.BaseAccount, settings.BaseRepo, gir)
+	}
 	if err != nil {
+		if interactive {
+			if branch, berr := GitFeatureBranch(ctx); berr == nil {
+				SaveDraft(ctx, branch, formatDraft(gir))
+			}
+		}
 		return 0, err
 	}
+	if interactive {
+		if branch, err := GitFeatureBranch(ctx); err == nil {
+			DiscardDraft(ctx, branch)
+		}
+	}
 
 	if interactive {
 		fmt.Printf("Created issue %d (%s)\n", *i.Number, *i.Title)
@@ -74,7 +105,7 @@ func NewIssue(ctx context.Context, client *github.Client, settings *Settings, in
 }
 
 // PopulateIssueInteractive creates a template, parses the template and returns the Issue number if the user is in interactive mode
-func PopulateIssueInteractive(ctx context.Context, client *github.Client, settings *Settings, inputTitle, inputDescription string, labelSlice []string) (ir *github.IssueRequest, err error) {
+func PopulateIssueInteractive(ctx context.Context, client *github.Client, settings *Settings, hc *HookContext, inputTitle, inputDescription string, labelSlice []string) (ir *github.IssueRequest, err error) {
 	labels, err := Labels(ctx, client, settings)
 	if err != nil {
 		return nil, err
@@ -92,11 +123,52 @@ func PopulateIssueInteractive(ctx context.Context, client *github.Client, settin
 	// fmt.Printf("drafting %s\n", tempFile.Name())
 	defer os.Remove(tempFile.Name())
 
+	reused, err := reuseDraft(ctx, tempFile, labelSet)
+	if err != nil {
+		return nil, err
+	}
+	// comments about the changes shown below the scissors line
+	var comments bytes.Buffer
+	if !reused {
+		err = seedTemplate(ctx, tempFile, &comments, settings, inputTitle, inputDescription, labelSlice)
+		if err != nil {
+			return nil, err
+		}
+	}
+	writeTemplateFooter(tempFile, comments.String(), labels, labelSet)
+	tempFile.Sync()
+	tempFile.Close()
+
+	title, description, selectedLabels, err := EditAndLintTemplate(ctx, settings, hc, tempFile.Name())
+	if err != nil {
+		SaveDraftFile(ctx, tempFile.Name())
+		return nil, err
+	}
+
+	issue := &github.IssueRequest{
+		Title:    &title,
+		Assignee: &settings.User,
+	}
+	if description != "" {
+		issue.Body = &description
+	}
+	if len(selectedLabels) > 0 {
+		issue.Labels = &selectedLabels
+	}
+
+	return issue, nil
+}
+
+// seedTemplate writes the initial title and description: the title and
+// description passed on the command line followed by a summary of the commits
+// on the branch (or the output of gitOpenPull.template). Comments that are not
+// part of the description are written to comments.
+func seedTemplate(ctx context.Context, w, comments io.Writer, settings *Settings, inputTitle, inputDescription string, labelSlice []string) error {
 	if inputTitle != "" {
-		fmt.Fprintf(tempFile, "%s\n", inputTitle)
+		fmt.Fprintf(w, "%s\n", inputTitle)
 	}
 	if inputDescription != "" {
-		fmt.Fprintf(tempFile, "%s\n", inputDescription)
+		fmt.Fprintf(w, "%s\n", inputDescription)
 	}
 
 	// seed template with commit history
@@ -106,135 +178,314 @@ func PopulateIssueInteractive(ctx context.Context, client *github.Client, settin
 	} else {
 		// fmt.Printf("merge base is %s\n", mergeBase)
 		if mergeBase != "" {
-			commits, err := Commits(ctx, mergeBase)
+			commits, err := LoadCommits(ctx, mergeBase)
 			if err != nil {
 				log.Printf("error getting commits %s", err)
 			}
-			for i, c := range commits {
-				// log.Printf("[%d] commit %s", i, c)
-				t, b, err := CommitDetails(ctx, c)
+			if settings.Template != "" {
+				data, err := NewTemplateData(ctx, settings, mergeBase, commits, labelSlice)
 				if err != nil {
-					return nil, err
+					return err
 				}
-				if t == "" {
-					continue
+				err = RenderTemplate(ctx, w, settings.Template, data)
+				if err != nil {
+					return err
 				}
-				switch i {
-				case 0:
-					fmt.Fprintf(tempFile, "%s\n", t)
-				case 1:
-					fmt.Fprintf(tempFile, "\n * %s\n", t)
-				default:
-					fmt.Fprintf(tempFile, " * %s\n", t)
+				io.WriteString(w, "\n")
+			} else {
+				err = WriteCommitSummary(w, commits, settings.DescriptionFormat)
+				if err != nil {
+					return err
 				}
-				if b != "" {
-					fmt.Fprintf(tempFile, "%s\n", b)
+				// templates include changed files with .Diffstat or .Files
+				err = writeChangedFiles(ctx, w, comments, settings, mergeBase)
+				if err != nil {
+					return err
 				}
 			}
 		}
 	}
-	io.WriteString(tempFile, "\n# Uncomment to assign labels\n")
+	return nil
+}
+
+// reuseDraft offers to start from a draft saved by a previous failed attempt,
+// writing its title and description and selecting its labels
+func reuseDraft(ctx context.Context, w io.Writer, labelSet map[string]bool) (bool, error) {
+	branch, err := GitFeatureBranch(ctx)
+	if err != nil {
+		return false, err
+	}
+	draft, saved, err := LoadDraft(ctx, branch)
+	if err != nil || draft == nil {
+		return false, err
+	}
+	yn, err := input.Ask(fmt.Sprintf("reuse draft description saved %s [Y/n]", saved.Format(time.DateTime)), "")
+	if err != nil {
+		return false, err
+	}
+	if yn != "" && strings.ToLower(yn) != "y" {
+		return false, nil
+	}
+	title, description, labels, err := ParseTemplate(bytes.NewReader(draft))
+	if err != nil {
+		// keep the draft as is, except for the footer which is written again
+		w.Write(cutTemplateFooter(draft))
+		return true, nil
+	}
+	fmt.Fprintf(w, "%s\n\n", title)
+	if description != "" {
+		fmt.Fprintf(w, "%s\n", description)
+	}
+	for _, l := range labels {
+		labelSet[l] = true
+	}
+	return true, nil
+}
+
+// writeChangedFiles writes a summary of the files changed since mergeBase in
+// the style set by gitOpenPull.diffstat. The "comment" style is written to
+// comments instead of the description.
+func writeChangedFiles(ctx context.Context, w, comments io.Writer, settings *Settings, mergeBase string) error {
+	switch settings.Diffstat {
+	case "off":
+		return nil
+	case "comment":
+		w = comments
+	}
+	files, err := DiffStat(ctx, mergeBase)
+	if err != nil {
+		return err
+	}
+	var owners []codeOwnersRule
+	if settings.DiffstatGroup == "codeowners" {
+		root, err := GitTopLevel(ctx)
+		if err != nil {
+			return err
+		}
+		owners, err = ReadCodeOwners(root)
+		if err != nil {
+			return err
+		}
+	}
+	groups, err := groupFiles(files, settings.DiffstatGroup, owners)
+	if err != nil {
+		return err
+	}
+	return WriteDiffstat(w, files, settings.Diffstat, groups)
+}
+
+// templateScissors separates the title and description from the rest of the
+// template, like the scissors line of `git commit --cleanup=scissors`
+const templateScissors = "# ------------------------ >8 ------------------------"
+
+// writeTemplateFooter writes the scissors line followed by comments, the list
+// of labels (uncommenting those in selected) and instructions for editing the
+// template
+func writeTemplateFooter(w io.Writer, comments string, labels []string, selected map[string]bool) {
+	fmt.Fprintf(w, "\n%s\n", templateScissors)
+	io.WriteString(w, "# Do not modify or remove the line above.\n# Everything below it is ignored except for Label: lines.\n")
+	io.WriteString(w, comments)
+	io.WriteString(w, "\n# Uncomment to assign labels\n")
 	for _, l := range labels {
 		// if labels are passed as command line input, uncomment them
-		if labelSet[l] {
-			fmt.Fprintf(tempFile, "Label: %s\n", l)
+		if selected[l] {
+			fmt.Fprintf(w, "Label: %s\n", l)
 			continue
 		}
-		fmt.Fprintf(tempFile, "# Label: %s\n", l)
+		fmt.Fprintf(w, "# Label: %s\n", l)
 	}
 
-	io.WriteString(tempFile, `
-# Please enter a title and description for your new issue. The first
-# line will be used as the issue title, and any subsequent lines will
-# be used as the issue description.
-#
-# Lines starting with '#' will be ignored.`)
+	io.WriteString(w, `
+# Please enter a title and description for your new issue above the
+# scissors line. The first line will be used as the issue title, and
+# any subsequent lines will be used as the issue description.
+`)
+}
 
-	tempFile.Sync()
-	tempFile.Close()
+// moveHookComments moves lines starting with '#' that a pre-process hook added
+// above the scissors line to below it. Templates without a scissors line
+// dropped them, so hooks use them for guidance, not markdown headings.
+func moveHookComments(before, after []byte) []byte {
+	seen := make(map[string]int)
+	for _, line := range strings.Split(string(cutTemplateFooter(before)), "\n") {
+		seen[line]++
+	}
+	lines := strings.Split(string(after), "\n")
+	var kept, moved []string
+	for i, line := range lines {
+		if strings.TrimSpace(line) == templateScissors {
+			if len(moved) == 0 {
+				return after
+			}
+			// keep the notes about the scissors line directly below it
+			j := i + 1
+			for j < len(lines) && j < i+3 && strings.HasPrefix(lines[j], "# ") {
+				j++
+			}
+			kept = append(kept, lines[i:j]...)
+			kept = append(kept, moved...)
+			kept = append(kept, lines[j:]...)
+			return []byte(strings.Join(kept, "\n"))
+		}
+		if strings.HasPrefix(line, "#") && seen[line] == 0 {
+			moved = append(moved, line)
+			continue
+		}
+		if seen[line] > 0 {
+			seen[line]--
+		}
+		kept = append(kept, line)
+	}
+	return after
+}
 
+// cutTemplateFooter returns the part of a template above the scissors line
+// (see writeTemplateFooter)
+func cutTemplateFooter(template []byte) []byte {
+	var n int
+	for _, line := range bytes.SplitAfter(template, []byte("\n")) {
+		if string(bytes.TrimSpace(line)) == templateScissors {
+			return bytes.TrimRight(template[:n], "\n")
+		}
+		n += len(line)
+	}
+	return template
+}
+
+// EditTemplate runs the pre process hook, the editor and the post process hook on filename
+func EditTemplate(ctx context.Context, settings *Settings, hc *HookContext, filename string) error {
 	// pre process template
-	if settings.PreProcess != "" {
-		cmd := exec.CommandContext(ctx, settings.PreProcess, tempFile.Name())
-		out, err := cmd.CombinedOutput()
+	before, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	err = RunHooks(ctx, settings, hc, "pre-process", settings.PreProcess, filename)
+	if err != nil {
+		return err
+	}
+	if len(settings.PreProcess) > 0 {
+		after, err := os.ReadFile(filename)
 		if err != nil {
-			log.Printf("error running pre process template: %s\n  error: %v\n  output: %s", settings.PreProcess, err, out)
-			return nil, err
+			return err
+		}
+		if moved := moveHookComments(before, after); !bytes.Equal(moved, after) {
+			if err := os.WriteFile(filename, moved, 0600); err != nil {
+				return err
+			}
 		}
 	}
 
-	cmd := exec.CommandContext(ctx, settings.Editor, tempFile.Name())
+	cmd := exec.CommandContext(ctx, settings.Editor, filename)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err = cmd.Run()
 	if err != nil {
-		tempFile.Close()
-		// os.Remove(tempFile.Name())
-		return nil, err
+		return err
 	}
 	if cmd.ProcessState != nil && !cmd.ProcessState.Success() {
-		return nil, fmt.Errorf("non-zero exit code from editor")
+		return fmt.Errorf("non-zero exit code from editor")
 	}
 
 	// post process template
-	if settings.PostProcess != "" {
-		cmd = exec.CommandContext(ctx, settings.PostProcess, tempFile.Name())
-		out, err := cmd.CombinedOutput()
+	return RunHooks(ctx, settings, hc, "post-process", settings.PostProcess, filename)
+}
+
+// EditAndLintTemplate edits and parses filename, then lints and scans the
+// result for credentials (see CheckSecrets). When there are lint errors or
+// possible credentials it offers to edit the template again.
+func EditAndLintTemplate(ctx context.Context, settings *Settings, hc *HookContext, filename string) (title, description string, labels []string, err error) {
+	for {
+		err = EditTemplate(ctx, settings, hc, filename)
 		if err != nil {
-			log.Printf("error running post process template: %s\n  error: %v\n  output: %s", settings.PostProcess, err, out)
-			return nil, err
+			return "", "", nil, err
+		}
+		title, description, labels, err = ParseTemplateFile(filename)
+		if err != nil {
+			return "", "", nil, err
+		}
+		results, err := Lint(settings, title, description)
+		if err != nil {
+			return "", "", nil, err
+		}
+		failed := PrintLint(os.Stderr, results)
+		secretsErr := CheckSecrets(os.Stderr, settings, title, description)
+		if secretsErr != nil && secretsErr != errSecrets {
+			return "", "", nil, secretsErr
+		}
+		if !failed && secretsErr == nil {
+			return title, description, labels, nil
+		}
+		yn, err := input.Ask("fix the title and description in your editor [Y/n]", "")
+		if err != nil {
+			return "", "", nil, err
+		}
+		if yn != "" && strings.ToLower(yn) != "y" {
+			if failed {
+				return "", "", nil, errLint
+			}
+			return "", "", nil, errSecrets
 		}
 	}
+}
 
-	// re-open the temp file
-	tempFile, err = os.Open(tempFile.Name())
+// ParseTemplateFile reads the title, description and labels from an edited template
+func ParseTemplateFile(filename string) (title, description string, labels []string, err error) {
+	f, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return "", "", nil, err
 	}
+	defer f.Close()
+	return ParseTemplate(f)
+}
 
-	var title string
-	var descriptions, selectedLabels []string
-	scanner := bufio.NewScanner(tempFile)
+// ParseTemplate reads the title, description and labels from an edited
+// template. The first non-empty line is the title and the lines after it, up
+// to the scissors line (see templateScissors), are the description. Below the
+// scissors line only lines starting with "Label:" are read, to select labels.
+// In a template without a scissors line lines starting with '#' are ignored
+// and "Label:" lines are read anywhere.
+func ParseTemplate(r io.Reader) (title, description string, labels []string, err error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		// log.Printf("line %#v", scanner.Text())
-		line := strings.TrimSpace(scanner.Text())
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", nil, err
+	}
+	scissors := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == templateScissors {
+			scissors = i
+			break
+		}
+	}
+
+	var descriptions []string
+	for i, text := range lines {
+		line := strings.TrimSpace(text)
+		footer := scissors != -1 && i >= scissors
+		comments := scissors == -1 || footer
 		switch {
-		case strings.HasPrefix(line, "Label:"):
+		case comments && strings.HasPrefix(line, "Label:"):
 			label := strings.TrimSpace(line[len("Label:"):])
 			if label != "" {
-				selectedLabels = append(selectedLabels, label)
+				labels = append(labels, label)
 			}
-		case strings.HasPrefix(line, "#"):
+		case footer, comments && strings.HasPrefix(line, "#"):
 		case title == "" && line != "":
 			title = line
 		default:
-			descriptions = append(descriptions, strings.TrimRight(scanner.Text(), " \t\r\n"))
-		}
-
-		if err := scanner.Err(); err != nil {
-			return nil, err
+			descriptions = append(descriptions, strings.TrimRight(text, " \t\r\n"))
 		}
 	}
 
-	description := strings.TrimSpace(strings.Join(descriptions, "\n"))
-
+	description = strings.TrimSpace(strings.Join(descriptions, "\n"))
 	if title == "" {
-		return nil, fmt.Errorf("missing title")
-	}
-
-	issue := &github.IssueRequest{
-		Title:    &title,
-		Assignee: &settings.User,
+		return "", "", nil, fmt.Errorf("missing title")
 	}
-	if description != "" {
-		issue.Body = &description
-	}
-	if len(selectedLabels) > 0 {
-		issue.Labels = &selectedLabels
-	}
-
-	return issue, nil
+	return title, description, labels, nil
 }
 
 // Labels returns all of the labels for a given repo
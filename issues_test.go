@@ -1,8 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/google/go-github/v60/github"
 )
 
 func TestDetectIssueNumber(t *testing.T) {
@@ -30,3 +37,167 @@ func TestDetectIssueNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTemplate(t *testing.T) {
+	type testCase struct {
+		template    string
+		title       string
+		description string
+		labels      []string
+		err         bool
+	}
+	tests := []testCase{
+		{"Title\n\nBody line\n * bullet\n# comment\nLabel: bug\n# Label: docs\n", "Title", "Body line\n * bullet", []string{"bug"}, false},
+		{"\n\n  Title  \nLabel: bug\nLabel: docs\n", "Title", "", []string{"bug", "docs"}, false},
+		{"# only comments\nLabel: bug\n", "", "", nil, true},
+		// above the scissors line '#' and Label: lines are part of the description
+		{"Title\n\n## Heading\nLabel: bug\n" + templateScissors + "\nignored\n# Label: docs\nLabel: ui\n", "Title", "## Heading\nLabel: bug", []string{"ui"}, false},
+		{"\n# Title\n" + templateScissors + "\n", "# Title", "", nil, false},
+		{templateScissors + "\nTitle\n", "", "", nil, true},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			title, description, labels, err := ParseTemplate(strings.NewReader(tc.template))
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v", err)
+			}
+			if title != tc.title || description != tc.description || strings.Join(labels, ",") != strings.Join(tc.labels, ",") {
+				t.Errorf("got %q %q %q expected %q %q %q", title, description, labels, tc.title, tc.description, tc.labels)
+			}
+		})
+	}
+}
+
+func TestTemplateRoundTrip(t *testing.T) {
+	title := "Add retries"
+	body := "# Summary\n\nRetry failed requests.\n\n## Testing\n\n * unit tests\n\nLabel: this is not a label"
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n\n%s\n", title, body)
+	writeTemplateFooter(&b, "\n# 1 file changed, +1 -0\n#   api.go +1\n", []string{"bug", "docs"}, map[string]bool{"bug": true})
+
+	gotTitle, gotBody, labels, err := ParseTemplate(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotTitle != title || gotBody != body {
+		t.Errorf("got %q %q expected %q %q", gotTitle, gotBody, title, body)
+	}
+	if strings.Join(labels, ",") != "bug" {
+		t.Errorf("got labels %q expected bug", labels)
+	}
+
+	// a saved draft parses the same way
+	for _, l := range [][]string{nil, {"docs"}} {
+		ir := &github.IssueRequest{Title: &title, Body: &body}
+		if l != nil {
+			ir.Labels = &l
+		}
+		gotTitle, gotBody, labels, err = ParseTemplate(bytes.NewReader(formatDraft(ir)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotTitle != title || gotBody != body || strings.Join(labels, ",") != strings.Join(l, ",") {
+			t.Errorf("got draft %q %q %q expected %q %q %q", gotTitle, gotBody, labels, title, body, l)
+		}
+	}
+}
+
+func TestSeedTemplateChangedFiles(t *testing.T) {
+	fixtureRepo(t)
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "-q", "--bare", "-b", "main", "acme.git"},
+		{"remote", "add", "acme", "acme.git"},
+		{"push", "-q", "acme", "main"},
+	} {
+		if _, err := RunGit(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile("api.go", []byte("package api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("template.txt", []byte("{{ (index .Commits 0).Subject }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "api.go"}, {"commit", "-q", "-m", "Add api"}} {
+		if _, err := RunGit(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type testCase struct {
+		template string
+		comments string
+	}
+	tests := []testCase{
+		{"", "\n# 1 file changed, +1 -0\n#   api.go +1 -0\n"},
+		// templates use .Diffstat or .Files instead
+		{"template.txt", ""},
+	}
+	for _, tc := range tests {
+		settings := &Settings{BaseAccount: "acme", BaseBranch: "main", Diffstat: "comment", Template: tc.template}
+		var w, comments bytes.Buffer
+		if err := seedTemplate(ctx, &w, &comments, settings, "", "", nil); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(w.String(), "Add api\n") {
+			t.Errorf("%q: unexpected template %q", tc.template, w.String())
+		}
+		if comments.String() != tc.comments {
+			t.Errorf("%q: got comments %q expected %q", tc.template, comments.String(), tc.comments)
+		}
+	}
+}
+
+func TestCutTemplateFooter(t *testing.T) {
+	var footer bytes.Buffer
+	writeTemplateFooter(&footer, "# 1 file changed\n", []string{"bug"}, map[string]bool{"bug": true})
+	type testCase struct {
+		template string
+		expected string
+	}
+	tests := []testCase{
+		{"\n\n## Heading\n" + footer.String(), "\n\n## Heading"},
+		{"Title\n\nbody\n" + templateScissors + "\nLabel: bug\n", "Title\n\nbody"},
+		{"Title\n# no footer\n", "Title\n# no footer\n"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := string(cutTemplateFooter([]byte(tc.template))); got != tc.expected {
+			t.Errorf("got %q expected %q", got, tc.expected)
+		}
+	}
+}
+
+func TestEditTemplateHookComments(t *testing.T) {
+	fixtureRepo(t)
+	filename := filepath.Join(t.TempDir(), "template")
+	var b bytes.Buffer
+	b.WriteString("Add retries\n\n## Testing\n\nran the unit tests\n")
+	writeTemplateFooter(&b, "", nil, nil)
+	if err := os.WriteFile(filename, b.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// a hook that adds guidance as a comment after the title
+	hook := `!sed -i.bak '1a\
+# Describe how this was tested
+' "$1"`
+	settings := &Settings{Editor: "true", PreProcess: []string{hook}}
+	if err := EditTemplate(context.Background(), settings, nil, filename); err != nil {
+		t.Fatal(err)
+	}
+	title, description, _, err := ParseTemplateFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "## Testing\n\nran the unit tests"; title != "Add retries" || description != expected {
+		t.Errorf("got %q %q expected %q", title, description, expected)
+	}
+	edited, _ := os.ReadFile(filename)
+	if !strings.Contains(string(edited), templateScissors+"\n# Do not modify or remove the line above.\n# Everything below it is ignored except for Label: lines.\n# Describe how this was tested\n") {
+		t.Errorf("expected the hook's comment below the scissors line\n%s", edited)
+	}
+}
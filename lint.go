@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTitleLength is the longest title the title-length rule allows
+const maxTitleLength = 72
+
+// Lint rules and their default policy. Policies are configured with
+// gitOpenPull.lint, i.e. `imperative=error,placeholders=ignore`, and use the
+// same levels as preflight checks.
+var lintDefaults = map[string]string{
+	"title-length": "warn",
+	"imperative":   "warn",
+	"sections":     "error",
+	"placeholders": "warn",
+	"forbidden":    "error",
+}
+
+// lintRule checks a title and description, returning a message for each problem found
+type lintRule func(settings *Settings, title, body string) []string
+
+// lintRules are run in order by Lint
+var lintRules = []struct {
+	Name  string
+	Check lintRule
+}{
+	{"title-length", lintTitleLength},
+	{"imperative", lintImperative},
+	{"sections", lintSections},
+	{"placeholders", lintPlaceholders},
+	{"forbidden", lintForbidden},
+}
+
+var errLint = errors.New("the title or description has lint errors; see gitOpenPull.lint to change which rules are errors")
+
+// LintResult is a problem found with a title or description
+type LintResult struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// lintLevel returns the configured policy for a rule
+func (s Settings) lintLevel(rule string) string {
+	if level, ok := s.Lint[rule]; ok {
+		return level
+	}
+	return lintDefaults[rule]
+}
+
+// Lint checks a title and description against the rules in lintRules. It
+// returns the problems found by rules that are not ignored.
+func Lint(settings *Settings, title, body string) ([]LintResult, error) {
+	for rule, level := range settings.Lint {
+		if _, ok := lintDefaults[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q in gitOpenPull.lint", rule)
+		}
+		switch level {
+		case "error", "warn", "ignore":
+		default:
+			return nil, fmt.Errorf("invalid lint level %q for %s; expected error, warn or ignore", level, rule)
+		}
+	}
+
+	var results []LintResult
+	for _, rule := range lintRules {
+		level := settings.lintLevel(rule.Name)
+		if level == "ignore" {
+			continue
+		}
+		for _, message := range rule.Check(settings, title, body) {
+			results = append(results, LintResult{Rule: rule.Name, Level: level, Message: message})
+		}
+	}
+	return results, nil
+}
+
+// PrintLint writes lint results and reports if any of them are errors
+func PrintLint(w io.Writer, results []LintResult) bool {
+	var failed bool
+	for _, r := range results {
+		if r.Level == "error" {
+			failed = true
+		}
+		fmt.Fprintf(w, "%s: %s (%s)\n", r.Level, r.Message, r.Rule)
+	}
+	return failed
+}
+
+func lintTitleLength(settings *Settings, title, body string) []string {
+	if n := utf8.RuneCountInString(title); n > maxTitleLength {
+		return []string{fmt.Sprintf("title is %d characters; keep it to %d or less", n, maxTitleLength)}
+	}
+	return nil
+}
+
+// titlePrefixRe matches a conventional commit type (i.e. "fix(api)!: ") or
+// a "[tag] " at the start of a title
+var titlePrefixRe = regexp.MustCompile(`^(\[[^\]]*\]\s*|[a-zA-Z]+(\([^)]*\))?!?:\s*)+`)
+
+// nonImperative are words ending like a past tense, gerund or third person
+// verb that are fine to start a title with
+var nonImperative = map[string]bool{
+	"bring": true, "embed": true, "feed": true, "need": true, "seed": true, "shed": true, "speed": true,
+	"ring": true, "sing": true, "string": true, "swing": true, "thing": true, "wing": true,
+	"access": true, "address": true, "bias": true, "bless": true, "bypass": true, "compress": true,
+	"dismiss": true, "express": true, "focus": true, "gas": true, "pass": true, "press": true,
+	"process": true, "progress": true, "redress": true, "suppress": true, "toss": true,
+}
+
+// lintImperative flags titles that start with a past tense ("Added"), gerund
+// ("Adding") or third person ("Adds") verb instead of the imperative ("Add")
+func lintImperative(settings *Settings, title, body string) []string {
+	fields := strings.Fields(titlePrefixRe.ReplaceAllString(title, ""))
+	if len(fields) == 0 {
+		return nil
+	}
+	word := strings.ToLower(strings.Trim(fields[0], ".,:;!?`'\""))
+	if nonImperative[word] || len(word) < 4 {
+		return nil
+	}
+	switch {
+	case strings.HasSuffix(word, "ed"), strings.HasSuffix(word, "ing"):
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+	default:
+		return nil
+	}
+	return []string{fmt.Sprintf("title should start with an imperative verb (i.e. \"Fix\" not \"Fixed\" or \"Fixes\"); found %q", fields[0])}
+}
+
+// sectionName normalizes a line that may be a section heading (i.e.
+// "**Testing:**" or "Testing") for comparison with gitOpenPull.lintSections
+func sectionName(line string) string {
+	return strings.ToLower(strings.TrimRight(strings.Trim(strings.TrimSpace(line), "*_#= "), ": "))
+}
+
+// lintSections checks that each section listed in gitOpenPull.lintSections is
+// present in the description and followed by some content
+func lintSections(settings *Settings, title, body string) []string {
+	if len(settings.LintSections) == 0 {
+		return nil
+	}
+	required := make(map[string]string)
+	for _, s := range settings.LintSections {
+		required[sectionName(s)] = s
+	}
+	filled := make(map[string]bool)
+	var current string
+	for _, line := range strings.Split(body, "\n") {
+		if _, ok := required[sectionName(line)]; ok {
+			current = sectionName(line)
+			if _, ok := filled[current]; !ok {
+				filled[current] = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			// another markdown heading ends the section
+			current = ""
+			continue
+		}
+		if current != "" && strings.TrimSpace(line) != "" && !placeholderRe.MatchString(line) {
+			filled[current] = true
+		}
+	}
+	var o []string
+	for _, s := range settings.LintSections {
+		done, ok := filled[sectionName(s)]
+		switch {
+		case !ok:
+			o = append(o, fmt.Sprintf("missing section %q", s))
+		case !done:
+			o = append(o, fmt.Sprintf("section %q is empty", s))
+		}
+	}
+	return o
+}
+
+// placeholderRe matches text left over from a template: html comments,
+// unrendered template actions and TODO markers
+var placeholderRe = regexp.MustCompile(`<!--.*?-->|\{\{.*?\}\}|\b(TODO|TBD|FIXME)\b`)
+
+func lintPlaceholders(settings *Settings, title, body string) []string {
+	var o []string
+	for _, text := range []string{title, body} {
+		for _, m := range placeholderRe.FindAllString(text, -1) {
+			o = append(o, fmt.Sprintf("placeholder %q left in description", m))
+		}
+	}
+	return o
+}
+
+// lintForbidden checks for words listed in gitOpenPull.lintForbiddenWords
+func lintForbidden(settings *Settings, title, body string) []string {
+	var o []string
+	for _, word := range settings.LintForbiddenWords {
+		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+		if re.MatchString(title) || re.MatchString(body) {
+			o = append(o, fmt.Sprintf("forbidden word %q", word))
+		}
+	}
+	return o
+}
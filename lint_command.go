@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+)
+
+// runLint implements `git-open-pull lint [number]`. It lints the title and
+// description passed with --title and --description-file, or those of a pull
+// request.
+func runLint(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	title := fs.String("title", "", "title to lint instead of a pull request's")
+	description := fs.String("description-file", "", "path to a description to lint with --title")
+	asJSON := fs.Bool("json", false, "output as JSON")
+	fs.Parse(args)
+
+	var body string
+	if *title != "" {
+		settings, err := readSettingsConfig(ctx, *profile)
+		if err != nil {
+			return err
+		}
+		if *description != "" {
+			content, err := os.ReadFile(*description)
+			if err != nil {
+				return err
+			}
+			body = string(content)
+		}
+		return printLintResults(settings, *title, body, *asJSON)
+	}
+
+	settings, client, err := commandSetup(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	pr, err := pullRequestFromArgs(ctx, client, settings, fs.Args())
+	if err != nil {
+		return err
+	}
+	return printLintResults(settings, pr.GetTitle(), pr.GetBody(), *asJSON)
+}
+
+func printLintResults(settings *Settings, title, body string, asJSON bool) error {
+	results, err := Lint(settings, title, body)
+	if err != nil {
+		return err
+	}
+	var failed bool
+	if asJSON {
+		if results == nil {
+			results = []LintResult{}
+		}
+		for _, r := range results {
+			failed = failed || r.Level == "error"
+		}
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		if err := e.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		failed = PrintLint(os.Stdout, results)
+	}
+	if failed {
+		return errLint
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	type testCase struct {
+		name     string
+		settings Settings
+		title    string
+		body     string
+		expected []LintResult
+	}
+	tests := []testCase{
+		{name: "clean", title: "Add lint stage", body: "details"},
+		{
+			name:     "long title",
+			title:    strings.Repeat("a", 73),
+			expected: []LintResult{{"title-length", "warn", "title is 73 characters; keep it to 72 or less"}},
+		},
+		{
+			name:     "past tense",
+			title:    "Added lint stage",
+			expected: []LintResult{{"imperative", "warn", `title should start with an imperative verb (i.e. "Fix" not "Fixed" or "Fixes"); found "Added"`}},
+		},
+		{name: "third person", title: "fix(lint): adds rules", expected: []LintResult{{"imperative", "warn", `title should start with an imperative verb (i.e. "Fix" not "Fixed" or "Fixes"); found "adds"`}}},
+		{name: "gerund ignored", settings: Settings{Lint: map[string]string{"imperative": "ignore"}}, title: "Adding lint stage"},
+		{name: "imperative exceptions", title: "[api] Address review comments"},
+		{name: "process", title: "Process queued jobs"},
+		{
+			name:     "sections",
+			settings: Settings{LintSections: []string{"Testing", "Rollout"}},
+			title:    "Add lint stage",
+			body:     "**Testing:**\n\n<!-- how was this tested -->\n\nSummary",
+			expected: []LintResult{
+				{"sections", "error", `missing section "Rollout"`},
+				{"placeholders", "warn", `placeholder "<!-- how was this tested -->" left in description`},
+			},
+		},
+		{
+			name:     "empty section",
+			settings: Settings{LintSections: []string{"Testing"}},
+			title:    "Add lint stage",
+			body:     "Testing:\nTODO",
+			expected: []LintResult{
+				{"sections", "error", `section "Testing" is empty`},
+				{"placeholders", "warn", `placeholder "TODO" left in description`},
+			},
+		},
+		{name: "filled section", settings: Settings{LintSections: []string{"Testing"}}, title: "Add lint stage", body: "## Testing\nran go test"},
+		{
+			name:     "forbidden",
+			settings: Settings{LintForbiddenWords: []string{"hotfix", "internal.example.com"}, Lint: map[string]string{"forbidden": "warn"}},
+			title:    "Add Hotfix",
+			body:     "see https://internal.example.com/x",
+			expected: []LintResult{
+				{"forbidden", "warn", `forbidden word "hotfix"`},
+				{"forbidden", "warn", `forbidden word "internal.example.com"`},
+			},
+		},
+		{name: "forbidden partial word", settings: Settings{LintForbiddenWords: []string{"fix"}}, title: "Add prefix"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Lint(&tc.settings, tc.title, tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %#v expected %#v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLintPolicyErrors(t *testing.T) {
+	for _, policy := range []map[string]string{{"spelling": "warn"}, {"imperative": "fatal"}} {
+		if _, err := Lint(&Settings{Lint: policy}, "Add", ""); err == nil {
+			t.Errorf("expected error for %v", policy)
+		}
+	}
+}
+
+func TestLintSectionsTemplate(t *testing.T) {
+	type testCase struct {
+		name     string
+		body     string
+		expected []string
+	}
+	tests := []testCase{
+		{"heading", "## Testing\n\nran the unit tests", nil},
+		{"bold", "**Testing:**\nran the unit tests", nil},
+		{"empty heading", "## Testing\n\n<!-- how was this tested -->\n\n## Notes\n", []string{`section "Testing" is empty`}},
+		{"missing", "ran the unit tests", []string{`missing section "Testing"`}},
+	}
+	settings := &Settings{Editor: "true", LintSections: []string{"Testing"}, Lint: map[string]string{"placeholders": "ignore"}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "template")
+			var b bytes.Buffer
+			fmt.Fprintf(&b, "Add retries\n\n%s\n", tc.body)
+			writeTemplateFooter(&b, "", nil, nil)
+			if err := os.WriteFile(filename, b.Bytes(), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if tc.expected == nil {
+				// the editor leaves the template unchanged
+				_, description, _, err := EditAndLintTemplate(context.Background(), settings, &HookContext{}, filename)
+				if err != nil || description != tc.body {
+					t.Errorf("got %q %v expected %q", description, err, tc.body)
+				}
+				return
+			}
+			title, description, _, err := ParseTemplateFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := Lint(settings, title, description)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Message)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %q expected %q", got, tc.expected)
+			}
+		})
+	}
+}
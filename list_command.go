@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// OpenPullRequest is a summary of one of the user's open pull requests
+type OpenPullRequest struct {
+	Repo        string    `json:"repo"`
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Branch      string    `json:"branch"`
+	Draft       bool      `json:"draft"`
+	Checks      string    `json:"checks"`
+	CreatedAt   time.Time `json:"created_at"`
+	LocalBranch bool      `json:"local_branch"`
+}
+
+const openPullRequestsQuery = `query($q: String!) {
+  search(query: $q, type: ISSUE, first: 100) {
+    nodes {
+      ... on PullRequest {
+        number
+        title
+        url
+        headRefName
+        isDraft
+        createdAt
+        commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+      }
+    }
+  }
+}`
+
+// OpenPullRequests returns settings.User's open pull requests on settings.BaseAccount/settings.BaseRepo
+func OpenPullRequests(ctx context.Context, client *github.Client, settings *Settings) ([]OpenPullRequest, error) {
+	var result struct {
+		Search struct {
+			Nodes []struct {
+				Number      int
+				Title       string
+				URL         string
+				HeadRefName string
+				IsDraft     bool
+				CreatedAt   time.Time
+				Commits     struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								State string
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	repo := fmt.Sprintf("%s/%s", settings.BaseAccount, settings.BaseRepo)
+	q := fmt.Sprintf("repo:%s is:pr is:open author:%s sort:created-desc", repo, settings.User)
+	err := GraphQL(ctx, client, openPullRequestsQuery, map[string]interface{}{"q": q}, &result)
+	if err != nil {
+		return nil, err
+	}
+	var o []OpenPullRequest
+	for _, n := range result.Search.Nodes {
+		pr := OpenPullRequest{
+			Repo:      repo,
+			Number:    n.Number,
+			Title:     n.Title,
+			URL:       n.URL,
+			Branch:    n.HeadRefName,
+			Draft:     n.IsDraft,
+			CreatedAt: n.CreatedAt,
+		}
+		if len(n.Commits.Nodes) > 0 && n.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+			pr.Checks = n.Commits.Nodes[0].Commit.StatusCheckRollup.State
+		}
+		o = append(o, pr)
+	}
+	return o, nil
+}
+
+// age formats a duration as a short human readable value (i.e. 5m, 3h, 12d)
+func age(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// runList implements `git-open-pull list`
+func runList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	allProfiles := fs.Bool("all-profiles", false, "list pull requests for every configured profile")
+	asJSON := fs.Bool("json", false, "output as JSON")
+	fs.Parse(args)
+
+	profiles := []string{*profile}
+	if *allProfiles {
+		names, err := ProfileNames(ctx)
+		if err != nil {
+			return err
+		}
+		profiles = append([]string{""}, names...)
+	}
+
+	local := make(map[string]bool)
+	branches, err := LocalBranches(ctx)
+	if err != nil {
+		return err
+	}
+	for _, b := range branches {
+		local[b] = true
+	}
+
+	var prs []OpenPullRequest
+	seen := make(map[string]bool)
+	for _, p := range profiles {
+		settings, client, err := commandSetup(ctx, p)
+		if err != nil {
+			if p == "" && *allProfiles {
+				// the top level settings may be incomplete without a profile
+				continue
+			}
+			return err
+		}
+		key := fmt.Sprintf("%s %s/%s", settings.User, settings.BaseAccount, settings.BaseRepo)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		found, err := OpenPullRequests(ctx, client, settings)
+		if err != nil {
+			return err
+		}
+		for _, pr := range found {
+			pr.LocalBranch = local[pr.Branch]
+			prs = append(prs, pr)
+		}
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(prs)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tREPO\tNUMBER\tTITLE\tBRANCH\tDRAFT\tCHECKS\tAGE")
+	for _, pr := range prs {
+		var marker, draft string
+		if pr.LocalBranch {
+			marker = "*"
+		}
+		if pr.Draft {
+			draft = "draft"
+		}
+		fmt.Fprintf(w, "%s\t%s\t#%d\t%s\t%s\t%s\t%s\t%s\n", marker, pr.Repo, pr.Number, pr.Title, pr.Branch, draft, orNone(strings.ToLower(pr.Checks)), age(time.Since(pr.CreatedAt)))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println("\n* branch exists locally")
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAge(t *testing.T) {
+	type testCase struct {
+		d        time.Duration
+		expected string
+	}
+	tests := []testCase{
+		{0, "0m"},
+		{59 * time.Minute, "59m"},
+		{time.Hour, "1h"},
+		{47 * time.Hour, "47h"},
+		{48 * time.Hour, "2d"},
+		{30*24*time.Hour + time.Hour, "30d"},
+	}
+	for _, tc := range tests {
+		if got := age(tc.d); got != tc.expected {
+			t.Errorf("age(%s) got %q expected %q", tc.d, got, tc.expected)
+		}
+	}
+}
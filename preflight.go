@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Preflight checks and their default policy. A policy of "error" stops
+// git-open-pull, "warn" reports the problem and continues, and "ignore" skips
+// the check. Policies are configured with gitOpenPull.preflight, i.e.
+// `dirty=error,behind=ignore`
+var preflightDefaults = map[string]string{
+	"detached":  "error",
+	"dirty":     "warn",
+	"untracked": "warn",
+	"empty":     "error",
+	"behind":    "warn",
+}
+
+// PreflightResult is a failed preflight check
+type PreflightResult struct {
+	Check   string `json:"check"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// parsePreflightPolicy parses a comma separated list of check=level
+func parsePreflightPolicy(v string) map[string]string {
+	policy := make(map[string]string)
+	for _, entry := range splitList(v) {
+		check, level, _ := strings.Cut(entry, "=")
+		policy[strings.TrimSpace(check)] = strings.ToLower(strings.TrimSpace(level))
+	}
+	return policy
+}
+
+func formatPreflightPolicy(policy map[string]string) string {
+	var o []string
+	for check, level := range policy {
+		o = append(o, fmt.Sprintf("%s=%s", check, level))
+	}
+	sort.Strings(o)
+	return strings.Join(o, ",")
+}
+
+// preflightLevel returns the configured policy for a check
+func (s Settings) preflightLevel(check string) string {
+	if level, ok := s.Preflight[check]; ok {
+		return level
+	}
+	return preflightDefaults[check]
+}
+
+// Preflight checks that the working tree and branch are in a state to open a
+// pull request from. It returns the checks that failed and are not ignored.
+// Without fetch the branch is compared with the base branch as last fetched
+// (see BaseRef).
+func Preflight(ctx context.Context, settings *Settings, branch string, fetch bool) ([]PreflightResult, error) {
+	for check, level := range settings.Preflight {
+		if _, ok := preflightDefaults[check]; !ok {
+			return nil, fmt.Errorf("unknown preflight check %q in gitOpenPull.preflight", check)
+		}
+		switch level {
+		case "error", "warn", "ignore":
+		default:
+			return nil, fmt.Errorf("invalid preflight level %q for %s; expected error, warn or ignore", level, check)
+		}
+	}
+
+	var results []PreflightResult
+	add := func(check, message string) {
+		if level := settings.preflightLevel(check); level != "ignore" {
+			results = append(results, PreflightResult{Check: check, Level: level, Message: message})
+		}
+	}
+
+	if branch == "HEAD" {
+		add("detached", "HEAD is detached; check out a branch to open a pull request from")
+	}
+
+	changed, untracked, err := GitStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) > 0 {
+		add("dirty", fmt.Sprintf("uncommitted changes will not be included: %s", strings.Join(changed, ", ")))
+	}
+	if len(untracked) > 0 {
+		add("untracked", fmt.Sprintf("untracked files will not be included: %s", strings.Join(untracked, ", ")))
+	}
+
+	baseRef, mergeBase := "FETCH_HEAD", ""
+	if fetch {
+		mergeBase, err = MergeBase(ctx, settings)
+	} else {
+		baseRef = BaseRef(settings)
+		mergeBase, err = LocalMergeBase(ctx, settings)
+	}
+	if err != nil {
+		results = append(results, PreflightResult{Check: "base", Level: "warn", Message: fmt.Sprintf("unable to compare with %s %s: %s", settings.BaseAccount, settings.BaseBranch, err)})
+		return results, nil
+	}
+	commits, err := Commits(ctx, mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		add("empty", fmt.Sprintf("%s has no commits that are not in %s/%s", branch, settings.BaseAccount, settings.BaseBranch))
+	}
+	behind, err := CommitsBehind(ctx, baseRef)
+	if err != nil {
+		return nil, err
+	}
+	if behind > 0 {
+		add("behind", fmt.Sprintf("%s is %d commits behind %s/%s", branch, behind, settings.BaseAccount, settings.BaseBranch))
+	}
+	return results, nil
+}
+
+// PrintPreflight writes preflight results and reports if any of them are errors
+func PrintPreflight(w io.Writer, results []PreflightResult) bool {
+	var failed bool
+	for _, r := range results {
+		if r.Level == "error" {
+			failed = true
+		}
+		fmt.Fprintf(w, "%s: %s (%s)\n", r.Level, r.Message, r.Check)
+	}
+	return failed
+}
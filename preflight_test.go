@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParsePreflightPolicy(t *testing.T) {
+	type testCase struct {
+		value    string
+		expected map[string]string
+		format   string
+	}
+	tests := []testCase{
+		{"", map[string]string{}, ""},
+		{"dirty=error", map[string]string{"dirty": "error"}, "dirty=error"},
+		{" dirty = Error , behind=ignore,", map[string]string{"dirty": "error", "behind": "ignore"}, "behind=ignore,dirty=error"},
+		{"dirty", map[string]string{"dirty": ""}, "dirty="},
+	}
+	for _, tc := range tests {
+		got := parsePreflightPolicy(tc.value)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("parsePreflightPolicy(%q) got %v expected %v", tc.value, got, tc.expected)
+		}
+		if f := formatPreflightPolicy(got); f != tc.format {
+			t.Errorf("formatPreflightPolicy(%v) got %q expected %q", got, f, tc.format)
+		}
+	}
+}
+
+func TestPreflightWithoutFetch(t *testing.T) {
+	fixtureRepo(t)
+	ctx := context.Background()
+	// the remote is unreachable so fetching would fail
+	for _, args := range [][]string{
+		{"remote", "add", "acme", "/nonexistent"},
+		{"update-ref", "refs/remotes/acme/main", "HEAD"},
+		{"commit", "-q", "--allow-empty", "-m", "change"},
+	} {
+		if _, err := RunGit(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	settings := &Settings{BaseAccount: "acme", BaseBranch: "main"}
+	results, err := Preflight(ctx, settings, "main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("unexpected results %#v", results)
+	}
+
+	results, err = Preflight(ctx, settings, "main", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Check != "base" {
+		t.Errorf("got %#v expected a base warning from the failed fetch", results)
+	}
+}
+
+func TestPreflightChecks(t *testing.T) {
+	type testCase struct {
+		name string
+		// git commands run after tracked.txt is written
+		setup [][]string
+		// files written after setup
+		files    []string
+		policy   string
+		branch   string
+		expected []PreflightResult
+	}
+	commit := [][]string{{"add", "tracked.txt"}, {"commit", "-q", "-m", "change"}}
+	tests := []testCase{
+		{name: "clean", setup: commit},
+		{name: "detached", setup: append(commit, []string{"checkout", "-q", "--detach"}), branch: "HEAD", expected: []PreflightResult{{Check: "detached", Level: "error"}}},
+		{name: "dirty", setup: commit, files: []string{"tracked.txt"}, expected: []PreflightResult{{Check: "dirty", Level: "warn"}}},
+		{name: "dirty error", setup: commit, files: []string{"tracked.txt"}, policy: "dirty=error", expected: []PreflightResult{{Check: "dirty", Level: "error"}}},
+		{name: "untracked", setup: commit, files: []string{"new.txt"}, expected: []PreflightResult{{Check: "untracked", Level: "warn"}}},
+		{name: "untracked ignored", setup: commit, files: []string{"new.txt"}, policy: "untracked=ignore"},
+		{name: "empty", setup: [][]string{{"stash", "-q", "-u"}}, expected: []PreflightResult{{Check: "empty", Level: "error"}}},
+		{name: "empty warn", setup: [][]string{{"stash", "-q", "-u"}}, policy: "empty=warn", expected: []PreflightResult{{Check: "empty", Level: "warn"}}},
+		{name: "behind", setup: [][]string{
+			{"branch", "feature"},
+			{"add", "tracked.txt"},
+			{"commit", "-q", "-m", "upstream"},
+			{"update-ref", "refs/remotes/acme/main", "HEAD"},
+			{"checkout", "-q", "feature"},
+			{"commit", "-q", "--allow-empty", "-m", "change"},
+		}, branch: "feature", expected: []PreflightResult{{Check: "behind", Level: "warn"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixtureRepo(t)
+			ctx := context.Background()
+			if _, err := RunGit(ctx, "update-ref", "refs/remotes/acme/main", "HEAD"); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile("tracked.txt", []byte("one\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, args := range tc.setup {
+				if _, err := RunGit(ctx, args...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, f := range tc.files {
+				if err := os.WriteFile(f, []byte("two\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			branch := tc.branch
+			if branch == "" {
+				branch = "main"
+			}
+			settings := &Settings{BaseAccount: "acme", BaseBranch: "main", Preflight: parsePreflightPolicy(tc.policy)}
+			results, err := Preflight(ctx, settings, branch, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []PreflightResult
+			for _, r := range results {
+				got = append(got, PreflightResult{Check: r.Check, Level: r.Level})
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %v expected %v", results, tc.expected)
+			}
+		})
+	}
+}
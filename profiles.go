@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// profileConfig is a named set of settings from a
+// [gitOpenPull "profile.<name>"] git config section. A profile is selected with
+// --profile (or GITOPENPULL_PROFILE), or automatically when one of its `remote`
+// patterns matches a remote URL or one of its `gitdir` patterns matches the
+// repository directory.
+//
+//	[gitOpenPull "profile.work"]
+//	    token = ....
+//	    baseAccount = acme
+//	    remote = github.com/acme
+//	    gitdir = ~/src/acme/
+type profileConfig struct {
+	Name    string
+	Remotes []string
+	GitDirs []string
+	values  []profileValue
+}
+
+type profileValue struct {
+	key, value, scope string
+}
+
+const profilePrefix = "gitopenpull.profile."
+
+// parseProfileKey splits a git config key like `gitopenpull.profile.work.token`
+// into the profile name and setting name.
+func parseProfileKey(key string) (name, setting string, ok bool) {
+	if len(key) <= len(profilePrefix) || !strings.EqualFold(key[:len(profilePrefix)], profilePrefix) {
+		return "", "", false
+	}
+	key = key[len(profilePrefix):]
+	i := strings.LastIndex(key, ".")
+	if i <= 0 {
+		return "", "", false
+	}
+	return key[:i], strings.ToLower(key[i+1:]), true
+}
+
+// ProfileNames returns the names of all configured profiles
+func ProfileNames(ctx context.Context) ([]string, error) {
+	body, err := RunGit(ctx, "config", "--name-only", "--get-regexp", `^gitopenpull\.profile\.`)
+	if err != nil {
+		// git config exits 1 when nothing matches
+		return nil, nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, key := range strings.Fields(string(body)) {
+		if name, _, ok := parseProfileKey(key); ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// profileSettingKey returns the git config key for a setting within a profile
+func profileSettingKey(profile string, k settingKey) string {
+	return fmt.Sprintf("gitOpenPull.profile.%s.%s", profile, k.name())
+}
+
+func (p *profileConfig) add(key, value, scope string) {
+	switch key {
+	case "remote":
+		p.Remotes = append(p.Remotes, value)
+	case "gitdir":
+		p.GitDirs = append(p.GitDirs, value)
+	default:
+		p.values = append(p.values, profileValue{key, value, scope})
+	}
+}
+
+// apply overrides settings with the values set in the profile
+func (p *profileConfig) apply(s *Settings) {
+	multiSeen := make(map[string]bool)
+	for _, v := range p.values {
+		for _, k := range settingKeys {
+			if strings.EqualFold(k.name(), v.key) {
+				if k.Multi && !multiSeen[k.Key] {
+					multiSeen[k.Key] = true
+					k.set(s, "")
+				}
+				k.set(s, v.value)
+				s.setSource(k.Key, fmt.Sprintf("git config (%s) profile %s", v.scope, p.Name))
+			}
+		}
+	}
+}
+
+// selectProfile picks the profile named explicitly (or by GITOPENPULL_PROFILE),
+// or the first profile (by name) whose remote or gitdir rules match this
+// repository. It returns nil when no profile applies, along with a description
+// of why a profile was selected.
+func selectProfile(profiles map[string]*profileConfig, name string, remoteURLs []string, gitDir string) (*profileConfig, string, error) {
+	reason := "--profile"
+	if name == "" {
+		name, reason = os.Getenv("GITOPENPULL_PROFILE"), "env GITOPENPULL_PROFILE"
+	}
+	if name != "" {
+		p, ok := profiles[name]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown profile %q. Configure it with `git-open-pull config set --profile %s <key> <value>`", name, name)
+		}
+		return p, reason, nil
+	}
+
+	var names []string
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		p := profiles[n]
+		for _, pattern := range p.Remotes {
+			for _, u := range remoteURLs {
+				if matchRemote(pattern, u) {
+					return p, fmt.Sprintf("matched remote %s", pattern), nil
+				}
+			}
+		}
+		for _, pattern := range p.GitDirs {
+			if gitDir != "" && matchGitDir(pattern, gitDir) {
+				return p, fmt.Sprintf("matched gitdir %s", pattern), nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// remoteHostPath normalizes a git remote URL to host/owner/repo
+//
+//	git@github.com:jehiah/git-open-pull.git => github.com/jehiah/git-open-pull
+//	https://github.com/jehiah/git-open-pull => github.com/jehiah/git-open-pull
+func remoteHostPath(u string) string {
+	u = strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+	if i := strings.Index(u, "://"); i != -1 {
+		u = u[i+3:]
+	} else {
+		// scp-like syntax user@host:path
+		u = strings.Replace(u, ":", "/", 1)
+	}
+	if i := strings.Index(u, "@"); i != -1 && i < strings.Index(u, "/") {
+		u = u[i+1:]
+	}
+	host, rest, _ := strings.Cut(u, "/")
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		// drop port
+		host = h
+	}
+	return strings.ToLower(host) + "/" + rest
+}
+
+// matchRemote reports if a remote URL matches a host/owner pattern. Each path
+// segment of the pattern is matched with path.Match against the leading
+// segments of the remote, so `github.com/acme` and `*/acme` match
+// git@github.com:acme/widgets.git
+func matchRemote(pattern, remoteURL string) bool {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(remoteHostPath(remoteURL), "/")
+	if len(want) > len(got) {
+		return false
+	}
+	for i, w := range want {
+		if ok, _ := path.Match(strings.ToLower(w), strings.ToLower(got[i])); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchGitDir matches a repository directory against an includeIf style
+// gitdir pattern. A leading `~/` is expanded to the home directory and a
+// trailing `/` matches everything below that directory.
+func matchGitDir(pattern, dir string) bool {
+	if strings.HasPrefix(pattern, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pattern = home + pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(dir+"/", pattern)
+	}
+	ok, _ := filepath.Match(pattern, dir)
+	return ok
+}
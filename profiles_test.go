@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseProfileKey(t *testing.T) {
+	type testCase struct {
+		key, name, setting string
+		ok                 bool
+	}
+	tests := []testCase{
+		{"gitopenpull.profile.work.token", "work", "token", true},
+		{"gitopenpull.profile.acme.oss.baseaccount", "acme.oss", "baseaccount", true},
+		{"gitOpenPull.profile.Work.baseAccount", "Work", "baseaccount", true},
+		{"gitopenpull.profile.work", "", "", false},
+		{"gitopenpull.token", "", "", false},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			name, setting, ok := parseProfileKey(tc.key)
+			if name != tc.name || setting != tc.setting || ok != tc.ok {
+				t.Errorf("got %q %q %v expected %q %q %v for %q", name, setting, ok, tc.name, tc.setting, tc.ok, tc.key)
+			}
+		})
+	}
+}
+
+func TestMatchRemote(t *testing.T) {
+	type testCase struct {
+		pattern, url string
+		match        bool
+	}
+	tests := []testCase{
+		{"github.com/acme", "git@github.com:acme/widgets.git", true},
+		{"github.com/acme", "https://github.com/acme/widgets", true},
+		{"github.com/acme", "ssh://git@github.com:22/acme/widgets.git", true},
+		{"*/acme", "https://github.example.com/acme/widgets.git", true},
+		{"github.com/acme/widgets", "git@github.com:acme/gadgets.git", false},
+		{"github.com/acme", "git@github.com:jehiah/widgets.git", false},
+		{"github.example.com", "git@github.com:acme/widgets.git", false},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			if got := matchRemote(tc.pattern, tc.url); got != tc.match {
+				t.Errorf("got %v expected %v for %q %q", got, tc.match, tc.pattern, tc.url)
+			}
+		})
+	}
+}
+
+func TestMatchGitDir(t *testing.T) {
+	type testCase struct {
+		pattern, dir string
+		match        bool
+	}
+	tests := []testCase{
+		{"/src/acme/", "/src/acme/widgets", true},
+		{"/src/acme/", "/src/acme", true},
+		{"/src/acme/", "/src/acmecorp/widgets", false},
+		{"/src/*/widgets", "/src/acme/widgets", true},
+		{"/src/acme", "/src/acme/widgets", false},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			if got := matchGitDir(tc.pattern, tc.dir); got != tc.match {
+				t.Errorf("got %v expected %v for %q %q", got, tc.match, tc.pattern, tc.dir)
+			}
+		})
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	fixtureRepo(t)
+	ctx := context.Background()
+
+	names, err := ProfileNames(ctx)
+	if err != nil || len(names) != 0 {
+		t.Fatalf("got %q %v expected no profiles", names, err)
+	}
+	for _, args := range [][]string{
+		{"--global", "gitOpenPull.profile.work.baseAccount", "acme"},
+		{"--global", "gitOpenPull.profile.work.token", "secret"},
+		{"--local", "gitOpenPull.profile.acme.oss.remote", "github.com/acme"},
+		{"--local", "gitOpenPull.baseAccount", "jehiah"},
+	} {
+		if _, err := RunGit(ctx, append([]string{"config"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err = ProfileNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names, ","); got != "acme.oss,work" {
+		t.Errorf("got %q expected acme.oss,work", got)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// projectConfigFile is an optional file committed at the repository root with
+// shared team defaults. Keys are the gitOpenPull setting names; personal
+// settings (token, user, editor) are not allowed. Hooks are only run once the
+// repository is trusted with gitOpenPull.trustProjectHooks.
+//
+//	baseAccount = "acme"
+//	base = "main"
+//	labels = ["needs-review"]
+//	reviewers = ["jehiah", "acme/core"]
+//	preProcess = "scripts/pr-template.sh"
+const projectConfigFile = ".git-open-pull.toml"
+
+// readProjectConfig applies settings from a project config file if it exists.
+// Errors reference the file and line of the offending key.
+func readProjectConfig(filename string, s *Settings) error {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			return fmt.Errorf("%s:%d: %s", filename, pe.Position.Line, pe.Message)
+		}
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		line := keyLine(data, key)
+		k, ok := lookupProjectKey(key)
+		if !ok {
+			return fmt.Errorf("%s:%d: unknown setting %q", filename, line, key)
+		}
+		if k.Personal {
+			return fmt.Errorf("%s:%d: %q can not be set in a shared project config; use `git-open-pull config set %s`", filename, line, key, k.Key)
+		}
+		if k.Hook && !s.TrustProjectHooks {
+			s.ignoredHooks = append(s.ignoredHooks, fmt.Sprintf("%s (line %d)", key, line))
+			continue
+		}
+		if l, ok := values[key].([]interface{}); ok && k.Multi {
+			// a list sets each value, i.e. each command of a hook
+			k.set(s, "")
+			for _, e := range l {
+				v, err := projectValue(e)
+				if err != nil {
+					return fmt.Errorf("%s:%d: %s %s", filename, line, key, err)
+				}
+				k.set(s, v)
+			}
+			s.setSource(k.Key, fmt.Sprintf("%s:%d", projectConfigFile, line))
+			continue
+		}
+		v, err := projectValue(values[key])
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s %s", filename, line, key, err)
+		}
+		k.set(s, v)
+		s.setSource(k.Key, fmt.Sprintf("%s:%d", projectConfigFile, line))
+	}
+	return nil
+}
+
+// warnIgnoredHooks reports the hooks in the project config that are ignored
+// until the repository is trusted. Callers that run hooks use it once after
+// reading settings.
+func (s Settings) warnIgnoredHooks() {
+	if len(s.ignoredHooks) == 0 {
+		return
+	}
+	log.Printf("ignoring %s from %s; run `git config gitOpenPull.trustProjectHooks true` to run this repository's hooks", strings.Join(s.ignoredHooks, ", "), projectConfigFile)
+}
+
+// trustProjectHooks reports if the hooks in the project config may run. It
+// is read from git config (`git config --list` output) or the environment
+// before the project config is applied, as the file itself can not set it.
+func trustProjectHooks(gitConfig []byte) bool {
+	var trust bool
+	for _, line := range strings.Split(string(gitConfig), "\n") {
+		_, entry, _ := strings.Cut(line, "\t")
+		if key, v, ok := strings.Cut(entry, "="); ok && strings.EqualFold(key, "gitOpenPull.trustProjectHooks") {
+			trust = strings.EqualFold(v, "true")
+		}
+	}
+	if v := os.Getenv("GITOPENPULL_TRUST_PROJECT_HOOKS"); v != "" {
+		trust, _ = strconv.ParseBool(v)
+	}
+	return trust
+}
+
+// lookupProjectKey finds a setting by the last component of its git config key
+// (i.e. `baseAccount` for gitOpenPull.baseAccount)
+func lookupProjectKey(key string) (settingKey, bool) {
+	for _, k := range settingKeys {
+		if strings.EqualFold(k.name(), key) {
+			return k, true
+		}
+	}
+	return settingKey{}, false
+}
+
+// projectValue converts a TOML value to the string form used in git config
+func projectValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		var o []string
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return "", fmt.Errorf("expected a list of strings")
+			}
+			o = append(o, s)
+		}
+		return strings.Join(o, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// keyLine returns the line number a top level key is defined on, or 0
+func keyLine(data []byte, key string) int {
+	re := regexp.MustCompile(`^\s*"?` + regexp.QuoteMeta(key) + `"?\s*=`)
+	for i, line := range strings.Split(string(data), "\n") {
+		if re.MatchString(line) {
+			return i + 1
+		}
+	}
+	return 0
+}
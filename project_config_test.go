@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadProjectConfig(t *testing.T) {
+	type testCase struct {
+		name   string
+		config string
+		err    string
+	}
+	tests := []testCase{
+		{"valid", "baseAccount = \"acme\"\nlabels = [\"a\", \"b\"]\nmaintainersCanModify = false\n", ""},
+		{"personal", "base = \"main\"\n\ntoken = \"secret\"\n", ".git-open-pull.toml:3: \"token\" can not be set"},
+		{"unknown", "base = \"main\"\nbse = \"main\"\n", ".git-open-pull.toml:2: unknown setting \"bse\""},
+		{"syntax", "base = \n", ".git-open-pull.toml:1: "},
+		{"type", "labels = [1, 2]\n", ".git-open-pull.toml:1: labels expected a list of strings"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filename := filepath.Join(t.TempDir(), projectConfigFile)
+			if err := os.WriteFile(filename, []byte(tc.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			s := &Settings{MaintainersCanModify: true}
+			err := readProjectConfig(filename, s)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %s", err)
+				}
+				if s.BaseAccount != "acme" || strings.Join(s.Labels, ",") != "a,b" || s.MaintainersCanModify {
+					t.Errorf("unexpected settings %#v", s)
+				}
+				if got := s.Source("gitOpenPull.labels"); got != ".git-open-pull.toml:2" {
+					t.Errorf("got source %q", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("got error %v expected %q", err, tc.err)
+			}
+		})
+	}
+}
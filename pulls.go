@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// RequestReviewers requests a review from settings.Reviewers. Reviewers in the
+// form `org/team` are requested as a team; teams outside the BaseAccount
+// organization can not be requested and are reported as an error.
+func RequestReviewers(ctx context.Context, client *github.Client, settings *Settings, number int) error {
+	var req github.ReviewersRequest
+	var otherOrg []string
+	for _, r := range settings.Reviewers {
+		if org, team, ok := strings.Cut(r, "/"); ok {
+			// only teams in the repository's organization can be requested
+			if !strings.EqualFold(org, settings.BaseAccount) {
+				otherOrg = append(otherOrg, r)
+				continue
+			}
+			req.TeamReviewers = append(req.TeamReviewers, team)
+			continue
+		}
+		if strings.EqualFold(r, settings.User) {
+			// GitHub rejects requesting a review from the PR author
+			continue
+		}
+		req.Reviewers = append(req.Reviewers, r)
+	}
+	if len(req.Reviewers) != 0 || len(req.TeamReviewers) != 0 {
+		_, _, err := client.PullRequests.RequestReviewers(ctx, settings.BaseAccount, settings.BaseRepo, number, req)
+		if err != nil {
+			return err
+		}
+	}
+	if len(otherOrg) != 0 {
+		return fmt.Errorf("teams %s are not in the %s organization", strings.Join(otherOrg, ", "), settings.BaseAccount)
+	}
+	return nil
+}
+
+// FindPullRequest returns the open pull request for branch. The issue number
+// in the branch name (see DetectIssueNumber) is checked first, followed by
+// open pull requests with branch as their head. A merged or closed pull
+// request is not returned.
+func FindPullRequest(ctx context.Context, client *github.Client, settings *Settings, branch string) (*github.PullRequest, error) {
+	if n := DetectIssueNumber(branch); n != 0 {
+		pr, _, err := client.PullRequests.Get(ctx, settings.BaseAccount, settings.BaseRepo, n)
+		switch {
+		case err == nil && pr.GetHead().GetRef() == branch && pr.GetState() == "open":
+			return pr, nil
+		case err != nil && !isNotFound(err):
+			return nil, err
+		}
+	}
+	for _, owner := range []string{settings.User, settings.BaseAccount} {
+		prs, _, err := client.PullRequests.List(ctx, settings.BaseAccount, settings.BaseRepo, &github.PullRequestListOptions{
+			State: "open",
+			Head:  fmt.Sprintf("%s:%s", owner, branch),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(prs) > 0 {
+			return prs[0], nil
+		}
+	}
+	return nil, fmt.Errorf("no open pull request found for branch %s in %s/%s", branch, settings.BaseAccount, settings.BaseRepo)
+}
+
+// MarkReadyForReview converts a draft pull request to ready for review
+func MarkReadyForReview(ctx context.Context, client *github.Client, pr *github.PullRequest) error {
+	return GraphQL(ctx, client, `mutation($id: ID!) {
+  markPullRequestReadyForReview(input: {pullRequestId: $id}) {
+    clientMutationId
+  }
+}`, map[string]interface{}{"id": pr.GetNodeID()}, nil)
+}
+
+// mergeMethods maps --auto-merge values to GraphQL PullRequestMergeMethod
+var mergeMethods = map[string]string{
+	"merge":  "MERGE",
+	"squash": "SQUASH",
+	"rebase": "REBASE",
+}
+
+// EnableAutoMerge turns on auto-merge for a pull request with the given merge
+// method (merge, squash or rebase)
+func EnableAutoMerge(ctx context.Context, client *github.Client, settings *Settings, pr *github.PullRequest, method string) error {
+	mergeMethod, ok := mergeMethods[method]
+	if !ok {
+		return fmt.Errorf("invalid auto-merge method %q; expected merge, squash or rebase", method)
+	}
+	if pr.GetDraft() {
+		return fmt.Errorf("auto-merge can not be enabled on draft pull request #%d; mark it ready for review first", pr.GetNumber())
+	}
+	repo, _, err := client.Repositories.Get(ctx, settings.BaseAccount, settings.BaseRepo)
+	if err != nil {
+		return err
+	}
+	// allow_auto_merge may be omitted depending on the user's permissions
+	if repo.AllowAutoMerge != nil && !repo.GetAllowAutoMerge() {
+		return fmt.Errorf("auto-merge is not allowed for %s/%s; enable \"Allow auto-merge\" in the repository settings", settings.BaseAccount, settings.BaseRepo)
+	}
+	return GraphQL(ctx, client, `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) {
+    clientMutationId
+  }
+}`, map[string]interface{}{"id": pr.GetNodeID(), "method": mergeMethod}, nil)
+}
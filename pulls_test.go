@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestFindPullRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/widgets/pulls/12":
+			w.Write([]byte(`{"number": 12, "state": "open", "head": {"ref": "feature_12"}}`))
+		case "/repos/acme/widgets/pulls/14":
+			w.Write([]byte(`{"number": 14, "state": "closed", "head": {"ref": "feature_14"}}`))
+		case "/repos/acme/widgets/pulls":
+			switch r.URL.Query().Get("head") {
+			case "jehiah:feature_13":
+				w.Write([]byte(`[{"number": 30, "head": {"ref": "feature_13"}}]`))
+			case "jehiah:feature_14":
+				w.Write([]byte(`[{"number": 40, "head": {"ref": "feature_14"}}]`))
+			case "acme:other_12":
+				w.Write([]byte(`[{"number": 20, "head": {"ref": "other_12"}}]`))
+			default:
+				w.Write([]byte(`[]`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	settings := &Settings{User: "jehiah", BaseAccount: "acme", BaseRepo: "widgets"}
+
+	type testCase struct {
+		branch string
+		number int
+	}
+	tests := []testCase{
+		{"feature_12", 12},
+		// #12 is for a different branch
+		{"other_12", 20},
+		// #13 does not exist
+		{"feature_13", 30},
+		// #14 is closed; a later pull request for the branch is open
+		{"feature_14", 40},
+		{"missing", 0},
+	}
+	for _, tc := range tests {
+		pr, err := FindPullRequest(context.Background(), client, settings, tc.branch)
+		if tc.number == 0 {
+			if err == nil {
+				t.Errorf("%s: expected error got #%d", tc.branch, pr.GetNumber())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.branch, err)
+			continue
+		}
+		if pr.GetNumber() != tc.number {
+			t.Errorf("%s: got #%d expected #%d", tc.branch, pr.GetNumber(), tc.number)
+		}
+	}
+}
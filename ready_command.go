@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+// runReady implements `git-open-pull ready [number]`
+func runReady(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("ready", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	requestReviewers := fs.Bool("request-reviewers", true, "request a review from gitOpenPull.reviewers")
+	fs.Parse(args)
+
+	settings, client, err := commandSetup(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	pr, err := pullRequestFromArgs(ctx, client, settings, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if !pr.GetDraft() {
+		fmt.Printf("pull request #%d is already ready for review\n", pr.GetNumber())
+	} else {
+		err = MarkReadyForReview(ctx, client, pr)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("marked pull request #%d ready for review\n", pr.GetNumber())
+	}
+
+	if *requestReviewers && len(settings.Reviewers) > 0 {
+		err = RequestReviewers(ctx, client, settings, pr.GetNumber())
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Println(pr.GetHTMLURL())
+
+	if len(settings.Callback) > 0 {
+		return RunCallback(ctx, client, settings, nil, pr.GetNumber())
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var errSecrets = errors.New("the title or description appears to contain credentials; remove them or pass --allow-secrets")
+
+type secretPattern struct {
+	Kind string
+	Re   *regexp.Regexp
+}
+
+// secretPatterns detect common credential formats
+var secretPatterns = []secretPattern{
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws_?secret_?(access_?)?key\W{0,3}[:=]\W{0,3}[A-Za-z0-9/+]{40}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+}
+
+// entropyCandidateRe matches strings long enough to be checked for entropy
+var entropyCandidateRe = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+
+// minSecretEntropy is the Shannon entropy (bits per character) above which a
+// long string of mixed case letters and digits is reported as a secret
+const minSecretEntropy = 4.5
+
+// SecretMatch is a possible credential found in a title or description
+type SecretMatch struct {
+	Kind  string `json:"kind"`
+	Field string `json:"field"`
+	Line  int    `json:"line"`
+	// the match with all but the last four characters redacted
+	Match string `json:"match"`
+}
+
+// ScanSecrets checks a title and description for credentials: common token
+// formats, high entropy strings and the patterns in gitOpenPull.secretPatterns
+func ScanSecrets(settings *Settings, title, body string) ([]SecretMatch, error) {
+	patterns := append([]secretPattern{}, secretPatterns...)
+	for _, p := range settings.SecretPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in gitOpenPull.secretPatterns: %w", p, err)
+		}
+		patterns = append(patterns, secretPattern{fmt.Sprintf("pattern %s", p), re})
+	}
+
+	var matches []SecretMatch
+	scan := func(field, text string) {
+		for i, line := range strings.Split(text, "\n") {
+			var found []string
+			for _, p := range patterns {
+				for _, m := range p.Re.FindAllString(line, -1) {
+					found = append(found, m)
+					matches = append(matches, SecretMatch{Kind: p.Kind, Field: field, Line: i + 1, Match: redact(m)})
+				}
+			}
+		candidates:
+			for _, c := range entropyCandidateRe.FindAllString(line, -1) {
+				for _, f := range found {
+					if strings.Contains(c, f) || strings.Contains(f, c) {
+						continue candidates
+					}
+				}
+				if isHighEntropy(c) {
+					matches = append(matches, SecretMatch{Kind: "high entropy string", Field: field, Line: i + 1, Match: redact(c)})
+				}
+			}
+		}
+	}
+	scan("title", title)
+	scan("description", body)
+	return matches, nil
+}
+
+// isHighEntropy reports if s mixes upper and lower case letters and digits
+// with an entropy above minSecretEntropy. Hex strings such as commit hashes
+// are at most 4 bits per character.
+func isHighEntropy(s string) bool {
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, r := range s {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+		digit = digit || unicode.IsDigit(r)
+		counts[r]++
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	var entropy float64
+	for _, n := range counts {
+		p := float64(n) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy > minSecretEntropy
+}
+
+// PrintSecrets writes the possible credentials found
+func PrintSecrets(w io.Writer, matches []SecretMatch) {
+	for _, m := range matches {
+		fmt.Fprintf(w, "possible %s in %s line %d: %s\n", m.Kind, m.Field, m.Line, m.Match)
+	}
+}
+
+// CheckSecrets scans a title and description before they are published. Unless
+// gitOpenPull.secretScan is "warn" (or --allow-secrets is passed) finding a
+// possible credential is an error.
+func CheckSecrets(w io.Writer, settings *Settings, title, body string) error {
+	switch settings.SecretScan {
+	case "off":
+		return nil
+	case "", "error", "warn":
+	default:
+		return fmt.Errorf("invalid gitOpenPull.secretScan %q; expected error, warn or off", settings.SecretScan)
+	}
+	matches, err := ScanSecrets(settings, title, body)
+	if err != nil {
+		return err
+	}
+	PrintSecrets(w, matches)
+	if len(matches) > 0 && settings.SecretScan != "warn" {
+		return errSecrets
+	}
+	return nil
+}
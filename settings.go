@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -32,144 +33,560 @@ type Settings struct {
 	// config: gitOpenPull.maintainersCanModify
 	MaintainersCanModify bool
 
-	// command to pre or post process the commit template
+	// Hooks are lists of commands run in order (see RunHooks). Each is
+	// configured by repeating the git config key; an empty value clears
+	// the commands configured before it.
+
+	// commands to pre or post process the commit template
 	// It is run with the first argument as the template name
-	PreProcess  string
-	PostProcess string
+	// config: gitOpenPull.preProcess, gitOpenPull.postProcess
+	PreProcess  []string
+	PostProcess []string
+	// run before an issue is created with the first argument a filename that contains the issue json
+	// config: gitOpenPull.preIssue
+	PreIssue []string
+	// run before and after the branch is pushed with the remote and branch as arguments
+	// config: gitOpenPull.prePush, gitOpenPull.postPush
+	PrePush  []string
+	PostPush []string
 	// callback is called after a PR is created. It's first argument is a filename that contains the PR json
-	Callback string
+	// config: gitOpenPull.callback
+	Callback []string
+	// run when git-open-pull fails with the error in GITOPENPULL_HOOK_ERROR
+	// config: gitOpenPull.onError
+	OnError []string
+
+	// how long a hook may run before it is stopped (i.e. "30s"); empty for no limit
+	// config: gitOpenPull.hookTimeout
+	HookTimeout string
+	// write a JSON file describing the branch, commits and settings for hooks (see HookContext)
+	// config: gitOpenPull.hookContext
+	HookContextFile bool
+	// run the hooks set in the project config file (see readProjectConfig)
+	// config: gitOpenPull.trustProjectHooks
+	TrustProjectHooks bool
+
+	// push branches directly to BaseAccount/BaseRepo instead of a fork: "true",
+	// "false" or "auto" to detect it (see DetectSameRepo)
+	// config: gitOpenPull.sameRepo
+	SameRepo string
+
+	// how commits are summarized in the drafted description: "plain" or "conventional"
+	// config: gitOpenPull.descriptionFormat
+	DescriptionFormat string
+
+	// text/template file used to draft the issue title and description (see TemplateData)
+	// config: gitOpenPull.template
+	Template string
+
+	// summary of changed files added to the drafted description: "off",
+	// "comment" (shown in the editor only) or "details" (a collapsible section)
+	// config: gitOpenPull.diffstat
+	Diffstat string
+	// how changed files are grouped: "none", "directory" or "codeowners"
+	// config: gitOpenPull.diffstatGroup
+	DiffstatGroup string
+
+	// how `sync` updates a branch with changes from the base branch: "rebase" or "merge"
+	// config: gitOpenPull.syncStrategy
+	SyncStrategy string
+
+	// policy (error, warn or ignore) for each preflight check
+	// config: gitOpenPull.preflight (i.e. dirty=error,behind=ignore)
+	Preflight map[string]string
+
+	// policy (error, warn or ignore) for each lint rule (see lintDefaults)
+	// config: gitOpenPull.lint (i.e. imperative=error,placeholders=ignore)
+	Lint map[string]string
+	// sections the description must contain and fill in
+	// config: gitOpenPull.lintSections (comma separated)
+	LintSections []string
+	// words that may not appear in the title or description
+	// config: gitOpenPull.lintForbiddenWords (comma separated)
+	LintForbiddenWords []string
+
+	// what to do when the title or description appears to contain a
+	// credential: "error" (the default), "warn" or "off"
+	// config: gitOpenPull.secretScan
+	SecretScan string
+	// additional regular expressions reported as secrets, one per value
+	// config: gitOpenPull.secretPatterns (repeated, or newline separated)
+	SecretPatterns []string
+
+	// default labels for new issues when --labels is not passed
+	// config: gitOpenPull.labels (comma separated)
+	Labels []string
+	// reviewers requested on new pull requests; `org/team` requests a team in the BaseAccount organization
+	// config: gitOpenPull.reviewers (comma separated)
+	Reviewers []string
 
 	// inferred from remote URLs if gitOpenPull.baseRepo is not set
 	DefaultBaseRepo string
+
+	// the name of the selected [gitOpenPull "profile.<name>"] section, if any
+	Profile string
+
+	// sources records where each value came from, keyed by git config key
+	sources map[string]string
+	// ignoredHooks are the hook keys (with their line) in the project config
+	// that were not applied because the repository is not trusted
+	ignoredHooks []string
 }
 
-// this function tries to get settings from the environment variables
-func GetEnvSettings(s *Settings) error {
-	token := os.Getenv("GITOPENPULL_TOKEN")
-	if token == "" {
-		token = os.Getenv("GITHUB_TOKEN")
-	}
-	if token != "" {
-		s.Token = token
-	}
-	user := os.Getenv("GITOPENPULL_USER")
-	if user != "" {
-		s.User = user
+// settingKey describes a configurable setting: the git config key it is read
+// from, the environment variable that overrides it and the git config scope
+// `config set` writes it to.
+type settingKey struct {
+	Key    string
+	Env    string
+	Global bool
+	Secret bool
+	// Personal settings can not be set in the project config file
+	Personal bool
+	// Multi settings are git config multivars; set adds a value
+	Multi bool
+	// Hook settings run commands; they are ignored in the project config
+	// file unless gitOpenPull.trustProjectHooks is set
+	Hook bool
+	// Bool settings must be a valid boolean when set from the environment
+	Bool  bool
+	value func(s *Settings) string
+	set   func(s *Settings, v string)
+}
+
+var settingKeys = []settingKey{
+	{
+		Key:      "github.user",
+		Env:      "GITOPENPULL_USER",
+		Global:   true,
+		Personal: true,
+		value:    func(s *Settings) string { return s.User },
+		set:      func(s *Settings, v string) { s.User = v },
+	},
+	{
+		Key:      "gitOpenPull.token",
+		Env:      "GITOPENPULL_TOKEN",
+		Global:   true,
+		Secret:   true,
+		Personal: true,
+		value:    func(s *Settings) string { return s.Token },
+		set:      func(s *Settings, v string) { s.Token = v },
+	},
+	{
+		Key:   "gitOpenPull.baseAccount",
+		Env:   "GITOPENPULL_BASE_ACCOUNT",
+		value: func(s *Settings) string { return s.BaseAccount },
+		set:   func(s *Settings, v string) { s.BaseAccount = v },
+	},
+	{
+		Key:   "gitOpenPull.baseRepo",
+		Env:   "GITOPENPULL_BASE_REPO",
+		value: func(s *Settings) string { return s.BaseRepo },
+		set:   func(s *Settings, v string) { s.BaseRepo = v },
+	},
+	{
+		Key:   "gitOpenPull.base",
+		Env:   "GITOPENPULL_BASE_BRANCH",
+		value: func(s *Settings) string { return s.BaseBranch },
+		set:   func(s *Settings, v string) { s.BaseBranch = v },
+	},
+	{
+		Key:   "gitOpenPull.maintainersCanModify",
+		Env:   "GITOPENPULL_MAINTAINERS_CAN_MODIFY",
+		Bool:  true,
+		value: func(s *Settings) string { return strconv.FormatBool(s.MaintainersCanModify) },
+		set:   func(s *Settings, v string) { s.MaintainersCanModify = strings.EqualFold(v, "true") },
+	},
+	{
+		Key:   "gitOpenPull.preProcess",
+		Env:   "GITOPENPULL_PRE_PROCESS",
+		Multi: true,
+		Hook:  true,
+		value: func(s *Settings) string { return strings.Join(s.PreProcess, "\n") },
+		set:   func(s *Settings, v string) { s.PreProcess = appendHook(s.PreProcess, v) },
+	},
+	{
+		Key:   "gitOpenPull.postProcess",
+		Env:   "GITOPENPULL_POST_PROCESS",
+		Multi: true,
+		Hook:  true,
+		value: func(s *Settings) string { return strings.Join(s.PostProcess, "\n") },
+		set:   func(s *Settings, v string) { s.PostProcess = appendHook(s.PostProcess, v) },
+	},
+	{
+		Key:   "gitOpenPull.preIssue",
+		Env:   "GITOPENPULL_PRE_ISSUE",
+		Multi: true,
+		Hook:  true,
+		value: func(s *Settings) string { return strings.Join(s.PreIssue, "\n") },
+		set:   func(s *Settings, v string) { s.PreIssue = appendHook(s.PreIssue, v) },
+	},
+	{
+		Key:   "gitOpenPull.prePush",
+		Env:   "GITOPENPULL_PRE_PUSH",
+		Multi: true,
+		Hook:  true,
+		value: func(s *Settings) string { return strings.Join(s.PrePush, "\n") },
+		set:   func(s *Settings, v string) { s.PrePush = appendHook(s.PrePush, v) },
+	},
+	{
+		Key:   "gitOpenPull.postPush",
+		Env:   "GITOPENPULL_POST_PUSH",
+		Multi: true,
+		Hook:  true,
+		value: func(s *Settings) string { return strings.Join(s.PostPush, "\n") },
+		set:   func(s *Settings, v string) { s.PostPush = appendHook(s.PostPush, v) },
+	},
+	{
+		Key:   "gitOpenPull.callback",
+		Env:   "GITOPENPULL_CALLBACK",
+		Multi: true,
+		Hook:  true,
+		value: func(s *Settings) string { return strings.Join(s.Callback, "\n") },
+		set:   func(s *Settings, v string) { s.Callback = appendHook(s.Callback, v) },
+	},
+	{
+		Key:   "gitOpenPull.onError",
+		Env:   "GITOPENPULL_ON_ERROR",
+		Multi: true,
+		Hook:  true,
+		value: func(s *Settings) string { return strings.Join(s.OnError, "\n") },
+		set:   func(s *Settings, v string) { s.OnError = appendHook(s.OnError, v) },
+	},
+	{
+		Key:   "gitOpenPull.hookTimeout",
+		Env:   "GITOPENPULL_HOOK_TIMEOUT",
+		value: func(s *Settings) string { return s.HookTimeout },
+		set:   func(s *Settings, v string) { s.HookTimeout = v },
+	},
+	{
+		Key:   "gitOpenPull.hookContext",
+		Env:   "GITOPENPULL_HOOK_CONTEXT",
+		Bool:  true,
+		value: func(s *Settings) string { return strconv.FormatBool(s.HookContextFile) },
+		set:   func(s *Settings, v string) { s.HookContextFile = strings.EqualFold(v, "true") },
+	},
+	{
+		Key:      "gitOpenPull.trustProjectHooks",
+		Env:      "GITOPENPULL_TRUST_PROJECT_HOOKS",
+		Personal: true,
+		Bool:     true,
+		value:    func(s *Settings) string { return strconv.FormatBool(s.TrustProjectHooks) },
+		set:      func(s *Settings, v string) { s.TrustProjectHooks = strings.EqualFold(v, "true") },
+	},
+	{
+		Key:   "gitOpenPull.sameRepo",
+		Env:   "GITOPENPULL_SAME_REPO",
+		value: func(s *Settings) string { return s.SameRepo },
+		set:   func(s *Settings, v string) { s.SameRepo = strings.ToLower(v) },
+	},
+	{
+		Key:   "gitOpenPull.descriptionFormat",
+		Env:   "GITOPENPULL_DESCRIPTION_FORMAT",
+		value: func(s *Settings) string { return s.DescriptionFormat },
+		set:   func(s *Settings, v string) { s.DescriptionFormat = strings.ToLower(v) },
+	},
+	{
+		Key:   "gitOpenPull.template",
+		Env:   "GITOPENPULL_TEMPLATE",
+		value: func(s *Settings) string { return s.Template },
+		set:   func(s *Settings, v string) { s.Template = v },
+	},
+	{
+		Key:   "gitOpenPull.diffstat",
+		Env:   "GITOPENPULL_DIFFSTAT",
+		value: func(s *Settings) string { return s.Diffstat },
+		set:   func(s *Settings, v string) { s.Diffstat = strings.ToLower(v) },
+	},
+	{
+		Key:   "gitOpenPull.diffstatGroup",
+		Env:   "GITOPENPULL_DIFFSTAT_GROUP",
+		value: func(s *Settings) string { return s.DiffstatGroup },
+		set:   func(s *Settings, v string) { s.DiffstatGroup = strings.ToLower(v) },
+	},
+	{
+		Key:   "gitOpenPull.syncStrategy",
+		Env:   "GITOPENPULL_SYNC_STRATEGY",
+		value: func(s *Settings) string { return s.SyncStrategy },
+		set:   func(s *Settings, v string) { s.SyncStrategy = strings.ToLower(v) },
+	},
+	{
+		Key:   "gitOpenPull.preflight",
+		Env:   "GITOPENPULL_PREFLIGHT",
+		value: func(s *Settings) string { return formatPreflightPolicy(s.Preflight) },
+		set:   func(s *Settings, v string) { s.Preflight = parsePreflightPolicy(v) },
+	},
+	{
+		Key:   "gitOpenPull.lint",
+		Env:   "GITOPENPULL_LINT",
+		value: func(s *Settings) string { return formatPreflightPolicy(s.Lint) },
+		set:   func(s *Settings, v string) { s.Lint = parsePreflightPolicy(v) },
+	},
+	{
+		Key:   "gitOpenPull.lintSections",
+		Env:   "GITOPENPULL_LINT_SECTIONS",
+		value: func(s *Settings) string { return strings.Join(s.LintSections, ",") },
+		set:   func(s *Settings, v string) { s.LintSections = splitList(v) },
+	},
+	{
+		Key:   "gitOpenPull.lintForbiddenWords",
+		Env:   "GITOPENPULL_LINT_FORBIDDEN_WORDS",
+		value: func(s *Settings) string { return strings.Join(s.LintForbiddenWords, ",") },
+		set:   func(s *Settings, v string) { s.LintForbiddenWords = splitList(v) },
+	},
+	{
+		Key:   "gitOpenPull.secretScan",
+		Env:   "GITOPENPULL_SECRET_SCAN",
+		value: func(s *Settings) string { return s.SecretScan },
+		set:   func(s *Settings, v string) { s.SecretScan = strings.ToLower(v) },
+	},
+	{
+		Key:   "gitOpenPull.secretPatterns",
+		Env:   "GITOPENPULL_SECRET_PATTERNS",
+		Multi: true,
+		value: func(s *Settings) string { return strings.Join(s.SecretPatterns, "\n") },
+		set:   func(s *Settings, v string) { s.SecretPatterns = appendLines(s.SecretPatterns, v) },
+	},
+	{
+		Key:   "gitOpenPull.labels",
+		Env:   "GITOPENPULL_LABELS",
+		value: func(s *Settings) string { return strings.Join(s.Labels, ",") },
+		set:   func(s *Settings, v string) { s.Labels = splitList(v) },
+	},
+	{
+		Key:   "gitOpenPull.reviewers",
+		Env:   "GITOPENPULL_REVIEWERS",
+		value: func(s *Settings) string { return strings.Join(s.Reviewers, ",") },
+		set:   func(s *Settings, v string) { s.Reviewers = splitList(v) },
+	},
+	{
+		Key:      "core.editor",
+		Env:      "GITOPENPULL_EDITOR",
+		Personal: true,
+		value:    func(s *Settings) string { return s.Editor },
+		set:      func(s *Settings, v string) { s.Editor = v },
+	},
+}
+
+// appendHook adds a hook command to a list; an empty command clears the list
+func appendHook(l []string, v string) []string {
+	if v == "" {
+		return nil
 	}
-	baseAccount := os.Getenv("GITOPENPULL_BASE_ACCOUNT")
-	if baseAccount != "" {
-		s.BaseAccount = baseAccount
+	return append(l, v)
+}
+
+// appendLines adds each non-empty line of v to a list; an empty value clears
+// the list. Regular expressions may contain commas so they are not split with
+// splitList.
+func appendLines(l []string, v string) []string {
+	if v == "" {
+		return nil
 	}
-	baseRepo := os.Getenv("GITOPENPULL_BASE_REPO")
-	if baseRepo != "" {
-		s.BaseRepo = baseRepo
+	for _, line := range strings.Split(v, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			l = append(l, line)
+		}
 	}
-	preProcess := os.Getenv("GITOPENPULL_PRE_PROCESS")
-	if preProcess != "" {
-		s.PreProcess = preProcess
+	return l
+}
+
+// splitList splits a comma separated list, trimming whitespace and dropping empty values
+func splitList(v string) []string {
+	var o []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			o = append(o, s)
+		}
 	}
-	postProcess := os.Getenv("GITOPENPULL_POST_PROCESS")
-	if postProcess != "" {
-		s.PostProcess = postProcess
+	return o
+}
+
+// lookupSettingKey finds a setting by its (case insensitive) git config key
+func lookupSettingKey(key string) (settingKey, bool) {
+	for _, k := range settingKeys {
+		if strings.EqualFold(k.Key, key) {
+			return k, true
+		}
 	}
-	callback := os.Getenv("GITOPENPULL_CALLBACK")
-	if callback != "" {
-		s.Callback = callback
+	return settingKey{}, false
+}
+
+// name returns the last component of the git config key (i.e. baseAccount)
+func (k settingKey) name() string {
+	return k.Key[strings.LastIndex(k.Key, ".")+1:]
+}
+
+// scope returns the git config flag for the scope a setting is written to
+func (k settingKey) scope() string {
+	if k.Global {
+		return "--global"
 	}
+	return "--local"
+}
 
-	baseBranch := os.Getenv("GITOPENPULL_BASE_BRANCH")
-	if baseBranch != "" {
-		s.BaseBranch = baseBranch
+// saveSetting persists a value to git config in the scope appropriate for key
+func saveSetting(ctx context.Context, key, value string) error {
+	k, ok := lookupSettingKey(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
 	}
+	_, err := RunGit(ctx, "config", k.scope(), k.Key, value)
+	return err
+}
 
-	mcmStr := os.Getenv("GITOPENPULL_MAINTAINERS_CAN_MODIFY")
-	if mcmStr != "" {
-		mcm, err := strconv.ParseBool(mcmStr)
-		if err != nil {
-			return err
-		}
-		s.MaintainersCanModify = mcm
+func (s *Settings) setSource(key, source string) {
+	if s.sources == nil {
+		s.sources = make(map[string]string)
 	}
+	s.sources[key] = source
+}
 
-	editor := os.Getenv("GITOPENPULL_EDITOR")
-	if editor != "" {
-		s.Editor = editor
+// Source returns a description of where the value for a git config key came from
+func (s Settings) Source(key string) string {
+	if src, ok := s.sources[key]; ok {
+		return src
 	}
+	return "unset"
+}
 
+// GetEnvSettings applies the environment variable overrides listed in
+// settingKeys. GITHUB_TOKEN is used when GITOPENPULL_TOKEN is not set.
+func GetEnvSettings(s *Settings) error {
+	for _, k := range settingKeys {
+		env := k.Env
+		v := os.Getenv(env)
+		if v == "" && k.Key == "gitOpenPull.token" {
+			env = "GITHUB_TOKEN"
+			v = os.Getenv(env)
+		}
+		if v == "" {
+			continue
+		}
+		if k.Bool {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %w", env, v, err)
+			}
+			v = strconv.FormatBool(b)
+		}
+		if k.Multi {
+			// the environment replaces values from config
+			k.set(s, "")
+		}
+		k.set(s, v)
+		s.setSource(k.Key, "env "+env)
+	}
 	return nil
 }
 
-func detectDefaultBaseBranch(ctx context.Context) string {
+func detectDefaultBaseBranch(ctx context.Context) (string, string) {
 	// Check for local branch names 'main' or 'master'
 	if _, err := RunGit(ctx, "show-ref", "--verify", "--quiet", "refs/heads/main"); err == nil {
-		return "main"
+		return "main", "detected (local branch main)"
 	}
 	if _, err := RunGit(ctx, "show-ref", "--verify", "--quiet", "refs/heads/master"); err == nil {
-		return "master"
+		return "master", "detected (local branch master)"
 	}
 
 	// Final fallback preserves previous behavior.
-	return "master"
+	return "master", "default"
 }
 
 // readSettingsConfig reads settings from git config and environment variables without prompting.
 // It returns the settings (possibly with empty required fields) and any hard error.
 // Settings.DefaultBaseRepo is set if a base repo can be inferred from remote URLs.
-func readSettingsConfig(ctx context.Context) (*Settings, error) {
-	body, err := RunGit(ctx, "config", "--list")
+//
+// Shared defaults from a committed .git-open-pull.toml (see readProjectConfig)
+// are applied first. Values from git config, then the selected profile (see
+// selectProfile) override those, and environment variables override all.
+func readSettingsConfig(ctx context.Context, profile string) (*Settings, error) {
+	body, err := RunGit(ctx, "config", "--list", "--show-scope")
 	if err != nil {
 		return nil, err
 	}
 	s := Settings{
-		BaseBranch: detectDefaultBaseBranch(ctx),
-		Editor:     "/usr/bin/vi",
+		Editor:               "/usr/bin/vi",
+		MaintainersCanModify: true,
+		SameRepo:             "auto",
+		SyncStrategy:         "rebase",
+		DescriptionFormat:    "plain",
+		Diffstat:             "comment",
+		DiffstatGroup:        "none",
+		SecretScan:           "error",
 	}
-	var maintainersCanModify string
+	var baseBranchSource string
+	s.BaseBranch, baseBranchSource = detectDefaultBaseBranch(ctx)
+	s.setSource("gitOpenPull.base", baseBranchSource)
+	s.setSource("gitOpenPull.maintainersCanModify", "default")
+	s.setSource("core.editor", "default")
+	s.setSource("gitOpenPull.sameRepo", "default")
+	s.setSource("gitOpenPull.syncStrategy", "default")
+	s.setSource("gitOpenPull.descriptionFormat", "default")
+	s.setSource("gitOpenPull.diffstat", "default")
+	s.setSource("gitOpenPull.diffstatGroup", "default")
+	s.setSource("gitOpenPull.secretScan", "default")
+
+	topLevel, _ := GitTopLevel(ctx)
+	if topLevel != "" {
+		s.TrustProjectHooks = trustProjectHooks(body)
+		if err := readProjectConfig(filepath.Join(topLevel, projectConfigFile), &s); err != nil {
+			return nil, err
+		}
+	}
+
+	profiles := make(map[string]*profileConfig)
+	var remoteURLs []string
+	multiSeen := make(map[string]bool)
 	scanner := bufio.NewScanner(bytes.NewBuffer(body))
 	for scanner.Scan() {
-		line := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		scope, entry, _ := strings.Cut(scanner.Text(), "\t")
+		line := strings.SplitN(strings.TrimSpace(entry), "=", 2)
 		if len(line) != 2 {
 			return nil, fmt.Errorf("Invalid line %#v", line)
 		}
-		switch line[0] {
-		case "github.user":
-			s.User = line[1]
-		case "gitopenpull.token":
-			s.Token = line[1]
-		case "gitopenpull.baseaccount":
-			s.BaseAccount = line[1]
-		case "gitopenpull.baserepo":
-			s.BaseRepo = line[1]
-		case "gitopenpull.base":
-			s.BaseBranch = line[1]
-		case "gitopenpull.maintainerscanmodify":
-			maintainersCanModify = line[1]
-			switch strings.ToLower(line[1]) {
-			case "true":
-				s.MaintainersCanModify = true
+		if name, key, ok := parseProfileKey(line[0]); ok {
+			p, ok := profiles[name]
+			if !ok {
+				p = &profileConfig{Name: name}
+				profiles[name] = p
 			}
-		case "gitopenpull.preprocess":
-			s.PreProcess = line[1]
-		case "gitopenpull.postprocess":
-			s.PostProcess = line[1]
-		case "gitopenpull.callback":
-			s.Callback = line[1]
-		case "core.editor":
-			s.Editor = line[1]
-		default:
-			if strings.HasSuffix(line[0], ".url") && strings.HasSuffix(line[1], ".git") && s.DefaultBaseRepo == "" {
-				chunks := strings.Split(line[1], "/")
-				s.DefaultBaseRepo = chunks[len(chunks)-1]
-				s.DefaultBaseRepo = s.DefaultBaseRepo[:len(s.DefaultBaseRepo)-4]
+			p.add(key, line[1], scope)
+			continue
+		}
+		if k, ok := lookupSettingKey(line[0]); ok {
+			if k.Multi && !multiSeen[k.Key] {
+				// git config values replace those from the project config
+				multiSeen[k.Key] = true
+				k.set(&s, "")
 			}
+			k.set(&s, line[1])
+			s.setSource(k.Key, fmt.Sprintf("git config (%s)", scope))
+			continue
+		}
+		if strings.HasPrefix(line[0], "remote.") && strings.HasSuffix(line[0], ".url") {
+			remoteURLs = append(remoteURLs, line[1])
+		}
+		if strings.HasSuffix(line[0], ".url") && strings.HasSuffix(line[1], ".git") && s.DefaultBaseRepo == "" {
+			chunks := strings.Split(line[1], "/")
+			s.DefaultBaseRepo = chunks[len(chunks)-1]
+			s.DefaultBaseRepo = s.DefaultBaseRepo[:len(s.DefaultBaseRepo)-4]
 		}
-	}
-	if maintainersCanModify == "" {
-		s.MaintainersCanModify = true
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
+
+	p, reason, err := selectProfile(profiles, profile, remoteURLs, topLevel)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		s.Profile = p.Name
+		s.setSource("profile", reason)
+		p.apply(&s)
+	}
+
 	err = GetEnvSettings(&s)
 	if err != nil {
 		return nil, err
@@ -179,8 +596,8 @@ func readSettingsConfig(ctx context.Context) (*Settings, error) {
 
 // LoadSettings extracts settings from $HOME/.gitconfig and .git/config, prompting
 // interactively for any missing required values and persisting them to git config.
-func LoadSettings(ctx context.Context) (*Settings, error) {
-	s, err := readSettingsConfig(ctx)
+func LoadSettings(ctx context.Context, profile string) (*Settings, error) {
+	s, err := readSettingsConfig(ctx, profile)
 	if err != nil {
 		return nil, err
 	}
@@ -192,9 +609,9 @@ func LoadSettings(ctx context.Context) (*Settings, error) {
 			return nil, err
 		}
 		if s.User == "" {
-			return nil, errors.New("GitHub username required. Set `git config --global github.user $USER`")
+			return nil, errors.New("GitHub username required. Set `git-open-pull config set github.user $USER`")
 		}
-		_, err = RunGit(ctx, "config", "--global", "github.user", s.User)
+		err = saveSetting(ctx, "github.user", s.User)
 		if err != nil {
 			return nil, err
 		}
@@ -206,9 +623,9 @@ func LoadSettings(ctx context.Context) (*Settings, error) {
 			return nil, err
 		}
 		if s.BaseAccount == "" {
-			return nil, fmt.Errorf("Destination GitHub username required. Set `git config gitOpenPull.baseAccount $USER`")
+			return nil, fmt.Errorf("Destination GitHub username required. Set `git-open-pull config set gitOpenPull.baseAccount $ACCOUNT`")
 		}
-		_, err = RunGit(ctx, "config", "gitOpenPull.baseAccount", s.BaseAccount)
+		err = saveSetting(ctx, "gitOpenPull.baseAccount", s.BaseAccount)
 		if err != nil {
 			return nil, err
 		}
@@ -220,9 +637,9 @@ func LoadSettings(ctx context.Context) (*Settings, error) {
 			return nil, err
 		}
 		if s.BaseRepo == "" {
-			return nil, fmt.Errorf("GitHub repository name required. Set `git config gitOpenPull.baseRepo $PROJECT`")
+			return nil, fmt.Errorf("GitHub repository name required. Set `git-open-pull config set gitOpenPull.baseRepo $PROJECT`")
 		}
-		_, err = RunGit(ctx, "config", "gitOpenPull.baseRepo", s.BaseRepo)
+		err = saveSetting(ctx, "gitOpenPull.baseRepo", s.BaseRepo)
 		if err != nil {
 			return nil, err
 		}
@@ -234,9 +651,9 @@ func LoadSettings(ctx context.Context) (*Settings, error) {
 			return nil, err
 		}
 		if s.Token == "" {
-			return nil, fmt.Errorf("GitHub token required. Set `git config --global gitOpenPull.token $TOKEN`")
+			return nil, fmt.Errorf("GitHub token required. Set `git-open-pull config set gitOpenPull.token $TOKEN`")
 		}
-		_, err = RunGit(ctx, "config", "--global", "gitOpenPull.token", s.Token)
+		err = saveSetting(ctx, "gitOpenPull.token", s.Token)
 		if err != nil {
 			return nil, err
 		}
@@ -252,16 +669,16 @@ func LoadSettings(ctx context.Context) (*Settings, error) {
 func (s Settings) RequiredHints() []string {
 	var hints []string
 	if s.User == "" {
-		hints = append(hints, "GitHub username required. Set `git config --global github.user $USER`")
+		hints = append(hints, "GitHub username required. Set `git-open-pull config set github.user $USER`")
 	}
 	if s.BaseAccount == "" {
-		hints = append(hints, "Destination GitHub username required. Set `git config gitOpenPull.baseAccount $ACCOUNT`")
+		hints = append(hints, "Destination GitHub username required. Set `git-open-pull config set gitOpenPull.baseAccount $ACCOUNT`")
 	}
 	if s.BaseRepo == "" {
-		hints = append(hints, "GitHub repository name required. Set `git config gitOpenPull.baseRepo $PROJECT`")
+		hints = append(hints, "GitHub repository name required. Set `git-open-pull config set gitOpenPull.baseRepo $PROJECT`")
 	}
 	if s.Token == "" {
-		hints = append(hints, "GitHub token required. Set `git config --global gitOpenPull.token $TOKEN` or set GITHUB_TOKEN env variable")
+		hints = append(hints, "GitHub token required. Set `git-open-pull config set gitOpenPull.token $TOKEN` or set GITHUB_TOKEN env variable")
 	}
 	return hints
 }
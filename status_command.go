@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// PullRequestStatus summarizes the state of a pull request
+type PullRequestStatus struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+	State      string `json:"state"`
+	Draft      bool   `json:"draft"`
+	HeadBranch string `json:"head_branch"`
+	BaseBranch string `json:"base_branch"`
+	// MERGEABLE, CONFLICTING or UNKNOWN
+	Mergeable string `json:"mergeable"`
+	// combined status of checks on the head commit: SUCCESS, PENDING, FAILURE, ERROR or EXPECTED
+	Checks string `json:"checks"`
+	// APPROVED, CHANGES_REQUESTED, REVIEW_REQUIRED or empty
+	ReviewDecision string `json:"review_decision"`
+	// the latest review state by reviewer
+	Reviews            map[string]string `json:"reviews"`
+	UnresolvedComments int               `json:"unresolved_comments"`
+}
+
+const pullRequestStatusQuery = `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      number
+      title
+      url
+      state
+      isDraft
+      mergeable
+      reviewDecision
+      headRefName
+      baseRefName
+      commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+      latestReviews(first: 100) { nodes { author { login } state } }
+      reviewThreads(first: 100) { nodes { isResolved comments { totalCount } } }
+    }
+  }
+}`
+
+// GetPullRequestStatus fetches the state, checks and reviews for a pull request
+func GetPullRequestStatus(ctx context.Context, client *github.Client, settings *Settings, number int) (*PullRequestStatus, error) {
+	var result struct {
+		Repository struct {
+			PullRequest struct {
+				Number         int
+				Title          string
+				URL            string
+				State          string
+				IsDraft        bool
+				Mergeable      string
+				ReviewDecision string
+				HeadRefName    string
+				BaseRefName    string
+				Commits        struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								State string
+							}
+						}
+					}
+				}
+				LatestReviews struct {
+					Nodes []struct {
+						Author struct{ Login string }
+						State  string
+					}
+				}
+				ReviewThreads struct {
+					Nodes []struct {
+						IsResolved bool
+						Comments   struct{ TotalCount int }
+					}
+				}
+			}
+		}
+	}
+	err := GraphQL(ctx, client, pullRequestStatusQuery, map[string]interface{}{
+		"owner":  settings.BaseAccount,
+		"repo":   settings.BaseRepo,
+		"number": number,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	pr := result.Repository.PullRequest
+	s := &PullRequestStatus{
+		Number:         pr.Number,
+		Title:          pr.Title,
+		URL:            pr.URL,
+		State:          pr.State,
+		Draft:          pr.IsDraft,
+		HeadBranch:     pr.HeadRefName,
+		BaseBranch:     pr.BaseRefName,
+		Mergeable:      pr.Mergeable,
+		ReviewDecision: pr.ReviewDecision,
+		Reviews:        make(map[string]string),
+	}
+	if len(pr.Commits.Nodes) > 0 && pr.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+		s.Checks = pr.Commits.Nodes[0].Commit.StatusCheckRollup.State
+	}
+	for _, r := range pr.LatestReviews.Nodes {
+		s.Reviews[r.Author.Login] = r.State
+	}
+	for _, t := range pr.ReviewThreads.Nodes {
+		if !t.IsResolved {
+			s.UnresolvedComments += t.Comments.TotalCount
+		}
+	}
+	return s, nil
+}
+
+// runStatus implements `git-open-pull status [number]`
+func runStatus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	asJSON := fs.Bool("json", false, "output as JSON")
+	fs.Parse(args)
+
+	settings, client, err := commandSetup(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	pr, err := pullRequestFromArgs(ctx, client, settings, fs.Args())
+	if err != nil {
+		return err
+	}
+	status, err := GetPullRequestStatus(ctx, client, settings, pr.GetNumber())
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(status)
+	}
+
+	state := strings.ToLower(status.State)
+	if status.Draft {
+		state += " (draft)"
+	}
+	fmt.Printf("#%d %s\n%s\n\n", status.Number, status.Title, status.URL)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "state\t%s\n", state)
+	fmt.Fprintf(w, "branch\t%s -> %s\n", status.HeadBranch, status.BaseBranch)
+	fmt.Fprintf(w, "mergeable\t%s\n", strings.ToLower(status.Mergeable))
+	fmt.Fprintf(w, "checks\t%s\n", orNone(strings.ToLower(status.Checks)))
+	fmt.Fprintf(w, "review decision\t%s\n", orNone(strings.ToLower(strings.ReplaceAll(status.ReviewDecision, "_", " "))))
+	var reviewers []string
+	for reviewer := range status.Reviews {
+		reviewers = append(reviewers, reviewer)
+	}
+	sort.Strings(reviewers)
+	for _, reviewer := range reviewers {
+		fmt.Fprintf(w, "  %s\t%s\n", reviewer, strings.ToLower(strings.ReplaceAll(status.Reviews[reviewer], "_", " ")))
+	}
+	fmt.Fprintf(w, "unresolved comments\t%d\n", status.UnresolvedComments)
+	return w.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
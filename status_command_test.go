@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestGetPullRequestStatus(t *testing.T) {
+	type testCase struct {
+		name     string
+		response string
+		expected PullRequestStatus
+	}
+	tests := []testCase{
+		{
+			name: "reviewed",
+			response: `{"data": {"repository": {"pullRequest": {
+				"number": 12, "title": "Add retries", "url": "https://github.com/acme/widgets/pull/12", "state": "OPEN",
+				"isDraft": false, "mergeable": "MERGEABLE", "reviewDecision": "CHANGES_REQUESTED",
+				"headRefName": "feature_12", "baseRefName": "main",
+				"commits": {"nodes": [{"commit": {"statusCheckRollup": {"state": "FAILURE"}}}]},
+				"latestReviews": {"nodes": [{"author": {"login": "jehiah"}, "state": "APPROVED"}, {"author": {"login": "mreiferson"}, "state": "CHANGES_REQUESTED"}]},
+				"reviewThreads": {"nodes": [{"isResolved": false, "comments": {"totalCount": 2}}, {"isResolved": true, "comments": {"totalCount": 5}}, {"isResolved": false, "comments": {"totalCount": 1}}]}
+			}}}}`,
+			expected: PullRequestStatus{
+				Number: 12, Title: "Add retries", URL: "https://github.com/acme/widgets/pull/12", State: "OPEN",
+				HeadBranch: "feature_12", BaseBranch: "main", Mergeable: "MERGEABLE", Checks: "FAILURE",
+				ReviewDecision:     "CHANGES_REQUESTED",
+				Reviews:            map[string]string{"jehiah": "APPROVED", "mreiferson": "CHANGES_REQUESTED"},
+				UnresolvedComments: 3,
+			},
+		},
+		{
+			name: "no checks",
+			response: `{"data": {"repository": {"pullRequest": {
+				"number": 13, "state": "OPEN", "isDraft": true, "mergeable": "UNKNOWN",
+				"commits": {"nodes": [{"commit": {"statusCheckRollup": null}}]},
+				"latestReviews": {"nodes": []}, "reviewThreads": {"nodes": []}
+			}}}}`,
+			expected: PullRequestStatus{Number: 13, State: "OPEN", Draft: true, Mergeable: "UNKNOWN", Reviews: map[string]string{}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.response))
+			}))
+			defer ts.Close()
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(ts.URL + "/")
+			settings := &Settings{BaseAccount: "acme", BaseRepo: "widgets"}
+			got, err := GetPullRequestStatus(context.Background(), client, settings, tc.expected.Number)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tc.expected) {
+				t.Errorf("got %#v expected %#v", *got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// runSync implements `git-open-pull sync`
+func runSync(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	profile := fs.String("profile", "", "settings profile to use")
+	strategy := fs.String("strategy", "", "rebase or merge (default gitOpenPull.syncStrategy)")
+	noPush := fs.Bool("no-push", false, "don't push the updated branch")
+	fs.Parse(args)
+
+	settings, client, err := commandSetup(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	// sync runs the push hooks
+	settings.warnIgnoredHooks()
+	if *strategy == "" {
+		*strategy = settings.SyncStrategy
+	}
+	if *strategy != "rebase" && *strategy != "merge" {
+		return fmt.Errorf("invalid sync strategy %q; expected rebase or merge", *strategy)
+	}
+
+	branch, err := GitFeatureBranch(ctx)
+	if err != nil {
+		return err
+	}
+	if branch == "HEAD" {
+		return fmt.Errorf("HEAD is detached; check out a branch to sync")
+	}
+	changed, _, err := GitStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("uncommitted changes in %s; commit or stash them before syncing", strings.Join(changed, ", "))
+	}
+
+	fmt.Printf("fetching %s from %s\n", settings.BaseBranch, settings.BaseAccount)
+	if err := FetchBase(ctx, settings); err != nil {
+		return err
+	}
+	base := fmt.Sprintf("%s/%s", settings.BaseAccount, settings.BaseBranch)
+	switch *strategy {
+	case "rebase":
+		fmt.Printf("rebasing %s onto %s\n", branch, base)
+		if err := RunGitVerbose(ctx, "rebase", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("rebase stopped; resolve the conflicts, `git add` them and run `git rebase --continue`, then re-run `git-open-pull sync` to push (or `git rebase --abort` to undo)")
+		}
+	case "merge":
+		fmt.Printf("merging %s into %s\n", base, branch)
+		if err := RunGitVerbose(ctx, "merge", "--no-edit", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("merge stopped; resolve the conflicts, `git add` them and run `git merge --continue`, then re-run `git-open-pull sync` to push (or `git merge --abort` to undo)")
+		}
+	}
+
+	if *noPush {
+		return nil
+	}
+	pr, err := FindPullRequest(ctx, client, settings, branch)
+	if err != nil {
+		fmt.Printf("not pushing: %s\n", err)
+		return nil
+	}
+	headRepo := pr.GetHead().GetRepo()
+	if headRepo == nil {
+		return fmt.Errorf("not pushing: the repository for pull request #%d's branch was deleted", pr.GetNumber())
+	}
+	remote := FindRemote(ctx, headRepo.GetHTMLURL())
+	if remote == "" {
+		remote = headRepo.GetOwner().GetLogin()
+	}
+	headRef := pr.GetHead().GetRef()
+	fmt.Printf("pushing %s to %s/%s (pull request #%d)\n", branch, remote, headRef, pr.GetNumber())
+	hc := &HookContext{Issue: pr.GetNumber(), PullRequest: pr.GetNumber()}
+	return PushWithHooks(ctx, settings, hc, remote, branch, PushOptions{RemoteBranch: headRef, ForceWithLease: true})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data available to a gitOpenPull.template file, which is
+// rendered with text/template to draft the issue title (the first line) and
+// description. For example (`with` guards the first commit so a branch
+// without commits still renders):
+//
+//	{{ with .Commits }}{{ (index . 0).Subject }}{{ end }}
+//
+//	## Changes
+//	{{ range .Commits }}
+//	 * {{ .Subject }} ({{ .SHA | short }})
+//	{{- end }}
+//
+//	{{ .Diffstat }}
+type TemplateData struct {
+	// Commits since the merge base, oldest first (see Commit)
+	Commits []Commit
+	// Trailers collected from all commits (i.e. Co-authored-by, Fixes #12)
+	Trailers []Trailer
+	// the current branch
+	Branch string
+	// the account, repository and branch the pull request targets
+	BaseAccount string
+	BaseRepo    string
+	BaseBranch  string
+	MergeBase   string
+	// labels selected with --labels or gitOpenPull.labels
+	Labels []string
+	// output of `git diff --stat` from the merge base to HEAD
+	Diffstat string
+	// files changed since the merge base with .Path, .Added, .Deleted and .Binary
+	Files []FileStat
+	// the GitHub username (github.user)
+	User string
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"short": func(sha string) string {
+		if len(sha) > 7 {
+			return sha[:7]
+		}
+		return sha
+	},
+}
+
+// NewTemplateData collects the data for rendering a template
+func NewTemplateData(ctx context.Context, settings *Settings, mergeBase string, commits []Commit, labels []string) (*TemplateData, error) {
+	branch, err := GitFeatureBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := &TemplateData{
+		Commits:     squashFixups(commits),
+		Trailers:    trailers(commits),
+		Branch:      branch,
+		BaseAccount: settings.BaseAccount,
+		BaseRepo:    settings.BaseRepo,
+		BaseBranch:  settings.BaseBranch,
+		MergeBase:   mergeBase,
+		Labels:      labels,
+		User:        settings.User,
+	}
+	if mergeBase != "" {
+		stat, err := RunGit(ctx, "diff", "--stat", mergeBase, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		data.Diffstat = strings.TrimRight(string(stat), "\n")
+		data.Files, err = DiffStat(ctx, mergeBase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// RenderTemplate renders the template file filename. Relative paths are
+// resolved from the repository root.
+func RenderTemplate(ctx context.Context, w io.Writer, filename string, data *TemplateData) error {
+	if !filepath.IsAbs(filename) {
+		if root, err := GitTopLevel(ctx); err == nil {
+			filename = filepath.Join(root, filename)
+		}
+	}
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	t, err := template.New(filepath.Base(filename)).Funcs(templateFuncs).Parse(string(body))
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering %s %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	base := fixtureRepo(t, "feat: add retries\n\nFixes #12", "fixup! feat: add retries", "docs: describe retries")
+	ctx := context.Background()
+	commits, err := LoadCommits(ctx, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := &Settings{User: "jehiah", BaseAccount: "acme", BaseRepo: "widgets", BaseBranch: "main"}
+	data, err := NewTemplateData(ctx, settings, base, commits, []string{"bug", "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := `{{ (index .Commits 0).Description }}
+{{ range .Commits }}
+ * [{{ .Type }}] {{ .Description }} by {{ .Author }} ({{ .SHA | short | len }})
+{{- end }}
+{{ range .Trailers }}{{ . }}{{ end }}
+{{ .Branch }} into {{ .BaseAccount }}/{{ .BaseRepo }}:{{ .BaseBranch }} labels {{ join .Labels "," }} by {{ .User }}
+`
+	if err := os.WriteFile(filepath.Join(".", "template.txt"), []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := RenderTemplate(ctx, &buf, "template.txt", data); err != nil {
+		t.Fatal(err)
+	}
+	expected := `add retries
+
+ * [feat] add retries by Author <author@example.com> (7)
+ * [docs] describe retries by Author <author@example.com> (7)
+Fixes #12
+main into acme/widgets:main labels bug,docs by jehiah
+`
+	if buf.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
+
+func TestRenderTemplateHeadings(t *testing.T) {
+	base := fixtureRepo(t, "feat: add retries", "docs: describe retries")
+	ctx := context.Background()
+	commits, err := LoadCommits(ctx, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := &Settings{User: "jehiah", BaseAccount: "acme", BaseRepo: "widgets", BaseBranch: "main"}
+	data, err := NewTemplateData(ctx, settings, base, commits, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the example from TemplateData
+	tmpl := `{{ with .Commits }}{{ (index . 0).Subject }}{{ end }}
+
+## Changes
+{{ range .Commits }}
+ * {{ .Subject }}
+{{- end }}
+`
+	if err := os.WriteFile("template.txt", []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := RenderTemplate(ctx, &buf, "template.txt", data); err != nil {
+		t.Fatal(err)
+	}
+	writeTemplateFooter(&buf, "", nil, nil)
+	title, description, _, err := ParseTemplate(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "## Changes\n\n * feat: add retries\n * docs: describe retries"
+	if title != "feat: add retries" || description != expected {
+		t.Errorf("got %q %q expected %q", title, description, expected)
+	}
+
+	// a branch without commits still renders
+	data.Commits = nil
+	buf.Reset()
+	if err := RenderTemplate(ctx, &buf, "template.txt", data); err != nil {
+		t.Fatal(err)
+	}
+}